@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var cacheCommands = []command{
+	{"purge", "purge [symbol]  delete every cached entry, or one symbol's quotes", runCachePurge},
+}
+
+func runCache(ctx context.Context, args []string) error {
+	return dispatch(ctx, "stonksctl cache", cacheCommands, args)
+}
+
+// runCachePurge deletes cached entries from the database. A running web
+// server keeps its in-memory copies until they expire.
+func runCachePurge(ctx context.Context, args []string) error {
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "usage: stonksctl cache purge [symbol]")
+		return errUsage
+	}
+	appContext, err := appContext()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return appContext.Deps.Cache.DeleteByPrefix("")
+	}
+	return appContext.Deps.QuoteService.ClearCache(ctx, strings.ToUpper(args[0]))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+)
+
+// runExport writes a symbol's historical prices, or its bars of -interval.
+// The CSV of prices has the columns import reads by default, so an export
+// can be imported into another database as it is.
+func runExport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	start := flags.String("start", "", "start of the range, a date or RFC 3339 timestamp (default all prices)")
+	end := flags.String("end", "", "end of the range (default now)")
+	interval := flags.String("interval", "", "export bars of this interval, 1h, 1d or 1w, instead of prices")
+	format := flags.String("format", "csv", "csv or json")
+	output := flags.String("o", "", "file to write to (default stdout)")
+	if err := parseFlags(flags, args, 1, "<symbol>"); err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("invalid format %q, want csv or json", *format)
+	}
+	startDate, endDate := time.Unix(0, 0).UTC(), time.Now().UTC()
+	var err error
+	if *start != "" {
+		if startDate, err = parseExportTime(*start, false); err != nil {
+			return fmt.Errorf("invalid start: %w", err)
+		}
+	}
+	if *end != "" {
+		if endDate, err = parseExportTime(*end, true); err != nil {
+			return fmt.Errorf("invalid end: %w", err)
+		}
+	}
+
+	appContext, err := appContext()
+	if err != nil {
+		return err
+	}
+	var quote core.Quote
+	if *interval != "" {
+		quote, err = appContext.Deps.QuoteService.GetQuoteBars(ctx, flags.Arg(0), *interval, startDate, endDate)
+	} else {
+		quote, err = appContext.Deps.QuoteService.GetQuote(ctx, flags.Arg(0), startDate, endDate)
+	}
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	switch {
+	case *format == "json":
+		return writeJSON(w, quote.ToSerializableQuote())
+	case *interval != "":
+		return writeBarsCSV(w, quote.Bars)
+	default:
+		return writePricesCSV(w, quote.HistoricalPrices)
+	}
+}
+
+// parseExportTime parses a date or RFC 3339 timestamp. A date that ends the
+// range includes all of that day.
+func parseExportTime(value string, endOfRange bool) (time.Time, error) {
+	if len(value) == len(time.DateOnly) {
+		t, err := time.Parse(time.DateOnly, value)
+		if endOfRange {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return t, err
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func writePricesCSV(w io.Writer, prices []core.SimplePrice) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"timestamp", "price", "currency", "source", "scrapedAt"}); err != nil {
+		return err
+	}
+	for _, p := range prices {
+		scrapedAt := ""
+		if p.ScrapedAt != nil {
+			scrapedAt = p.ScrapedAt.UTC().Format(time.RFC3339)
+		}
+		if err := cw.Write([]string{p.Timestamp.UTC().Format(time.RFC3339), p.Price.String(), p.Currency, p.Source, scrapedAt}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeBarsCSV(w io.Writer, bars []core.Bar) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"timestamp", "open", "high", "low", "close", "currency", "count"}); err != nil {
+		return err
+	}
+	for _, b := range bars {
+		record := []string{
+			b.Start.UTC().Format(time.RFC3339),
+			b.Open.String(), b.High.String(), b.Low.String(), b.Close.String(),
+			b.Currency,
+			strconv.Itoa(b.Count),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/importer"
+)
+
+// runImport is the CLI counterpart of POST /api/symbols/{symbol}/prices/import,
+// with the query parameters as flags.
+func runImport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	delimiter := flags.String("delimiter", ",", `field delimiter: a single character, "tab" or "semicolon"`)
+	decimal := flags.String("decimal", ".", `decimal separator, "." or ","`)
+	timestampColumn := flags.String("timestamp-column", "timestamp", "header of the timestamp column")
+	priceColumn := flags.String("price-column", "price", "header of the price column")
+	currencyColumn := flags.String("currency-column", "", `header of the currency column (default "currency" unless -currency is set)`)
+	currency := flags.String("currency", "", "currency of every row, instead of a currency column")
+	timestampFormat := flags.String("timestamp-format", "", "rfc3339, datetime, date, unix or a Go time layout (default tries rfc3339, datetime and date)")
+	timezone := flags.String("timezone", "UTC", "time zone of timestamps without an offset")
+	if err := parseFlags(flags, args, 2, "<symbol> <file.csv>"); err != nil {
+		return err
+	}
+
+	delimiterRune, err := importer.ParseDelimiter(*delimiter)
+	if err != nil {
+		return err
+	}
+	location, err := time.LoadLocation(*timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", *timezone, err)
+	}
+	file, err := os.Open(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	appContext, err := appContext()
+	if err != nil {
+		return err
+	}
+	result, err := appContext.Deps.ImportService.ImportPrices(ctx, flags.Arg(0), file, core.ImportOptions{
+		Delimiter:       delimiterRune,
+		Decimal:         *decimal,
+		TimestampColumn: *timestampColumn,
+		PriceColumn:     *priceColumn,
+		CurrencyColumn:  *currencyColumn,
+		Currency:        *currency,
+		TimestampFormat: *timestampFormat,
+		Location:        location,
+	})
+	if err != nil {
+		return err
+	}
+	return printJSON(result)
+}
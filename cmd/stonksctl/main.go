@@ -0,0 +1,149 @@
+// Command stonksctl runs admin operations against the stonks database
+// without going through the web server. It reads the same config as the
+// server, and prints its results as JSON.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/bjarke-xyz/stonks/internal/app"
+	"github.com/bjarke-xyz/stonks/internal/config"
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/logging"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+)
+
+// command is a subcommand, or a group of them that dispatches on the next
+// argument.
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+// errUsage means the arguments did not match any command; the usage has
+// already been printed.
+var errUsage = errors.New("invalid usage")
+
+var commands = []command{
+	{"migrate", "migrate up|down|redo|status|up-to <version>", runMigrate},
+	{"symbols", "symbols list|add", runSymbols},
+	{"sources", "sources list|add|attach", runSources},
+	{"scrape", "scrape [-force] <symbol id>  scrape one symbol now", runScrape},
+	{"backfill", "backfill [flags] <symbol id>  fetch and store past prices", runBackfill},
+	{"cache", "cache purge [symbol]", runCache},
+	{"import", "import [flags] <symbol> <file.csv>  import historical prices from CSV", runImport},
+	{"export", "export [flags] <symbol>  export historical prices as CSV or JSON", runExport},
+}
+
+func main() {
+	logging.Setup()
+	flag.Usage = func() { printUsage("stonksctl", commands) }
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	err := dispatch(ctx, "stonksctl", commands, flag.Args())
+	switch {
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "stonksctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// dispatch runs the command named by args[0] with the remaining arguments.
+func dispatch(ctx context.Context, group string, commands []command, args []string) error {
+	if len(args) > 0 {
+		for _, c := range commands {
+			if c.name == args[0] {
+				return c.run(ctx, args[1:])
+			}
+		}
+		fmt.Fprintf(os.Stderr, "%v: unknown command %q\n", group, args[0])
+	}
+	printUsage(group, commands)
+	return errUsage
+}
+
+func printUsage(group string, commands []command) {
+	fmt.Fprintf(os.Stderr, "usage: %v <command> [arguments]\n\ncommands:\n", group)
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %v\n", c.usage)
+	}
+}
+
+// openDB loads the config and opens the database, without migrating it.
+func openDB() (*config.Config, *sql.DB, error) {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading config: %w", err)
+	}
+	dbConn, err := db.Open(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening db: %w", err)
+	}
+	return cfg, dbConn, nil
+}
+
+// appContext migrates the database up, as the web server does on startup,
+// and wires the services.
+func appContext() (*core.AppContext, error) {
+	cfg, dbConn, err := openDB()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Migrate("up", dbConn, 0); err != nil {
+		return nil, err
+	}
+	return app.AppContext(cfg), nil
+}
+
+// parseFlags parses args into flags and checks that want positional
+// arguments remain, printing the usage if not.
+func parseFlags(flags *flag.FlagSet, args []string, want int, positional string) error {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: stonksctl %v [flags] %v\n", flags.Name(), positional)
+		flags.PrintDefaults()
+	}
+	// The flag set has already reported a parse error.
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() != want {
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", s)
+	}
+	return id, nil
+}
+
+// printJSON writes v to stdout, indented for reading.
+func printJSON(v any) error {
+	return writeJSON(os.Stdout, v)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+)
+
+// runMigrate migrates the database in a direction db.Migrate knows, or prints
+// which migrations are applied. Unlike every other command it does not
+// migrate up first.
+func runMigrate(ctx context.Context, args []string) error {
+	var version int64
+	switch {
+	case len(args) == 2 && args[0] == "up-to":
+		var err error
+		if version, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+	case len(args) != 1 || args[0] == "up-to":
+		fmt.Fprintln(os.Stderr, "usage: stonksctl migrate up|down|redo|status|up-to <version>")
+		return errUsage
+	}
+	_, dbConn, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	if args[0] == "status" {
+		states, err := db.MigrationStatus(ctx, dbConn)
+		if err != nil {
+			return err
+		}
+		return printJSON(states)
+	}
+	return db.Migrate(args[0], dbConn, version)
+}
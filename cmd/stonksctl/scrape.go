@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+)
+
+// runScrape scrapes one symbol and prints the run with its per-source results.
+// Passes are only kept apart within a process, so this may overlap a pass of
+// a running web server.
+func runScrape(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("scrape", flag.ContinueOnError)
+	force := flags.Bool("force", false, "scrape even while the symbol's market is closed")
+	if err := parseFlags(flags, args, 1, "<symbol id>"); err != nil {
+		return err
+	}
+	symbolID, err := parseID(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	appContext, err := appContext()
+	if err != nil {
+		return err
+	}
+	scraperService := appContext.Deps.ScraperService
+	runID, err := scraperService.NewRun(ctx, core.ScrapeTriggerCLI)
+	if err != nil {
+		return err
+	}
+	if err := scraperService.ScrapeSymbol(ctx, runID, symbolID, *force); err != nil {
+		if errors.Is(err, core.ErrMarketClosed) {
+			return fmt.Errorf("%w; pass -force to scrape anyway", err)
+		}
+		return err
+	}
+	run, err := scraperService.Run(context.WithoutCancel(ctx), runID)
+	if err != nil {
+		return err
+	}
+	return printJSON(run)
+}
+
+func runBackfill(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	var input core.BackfillInput
+	flags.StringVar(&input.Start, "start", "", "start of the range, a date or RFC 3339 timestamp (required)")
+	flags.StringVar(&input.End, "end", "", "end of the range (default now)")
+	flags.StringVar(&input.SourceID, "source", "", "source to fetch from (default the symbol's first that serves past prices)")
+	if err := parseFlags(flags, args, 1, "<symbol id>"); err != nil {
+		return err
+	}
+	symbolID, err := parseID(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	appContext, err := appContext()
+	if err != nil {
+		return err
+	}
+	result, err := appContext.Deps.ScraperService.Backfill(ctx, symbolID, input)
+	if err != nil {
+		return err
+	}
+	return printJSON(result)
+}
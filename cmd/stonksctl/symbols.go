@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+)
+
+var symbolCommands = []command{
+	{"list", "list  list every symbol", runSymbolsList},
+	{"add", "add [flags] <ticker> <isin>  add a symbol", runSymbolsAdd},
+}
+
+var sourceCommands = []command{
+	{"list", "list [-symbol id]  list the scraping sources, or one symbol's sources", runSourcesList},
+	{"add", "add [flags] <id> <name> <base url>  add a scraping source", runSourcesAdd},
+	{"attach", "attach [flags] <symbol id> <source id> <scrape url>  scrape a symbol from a source", runSourcesAttach},
+}
+
+func runSymbols(ctx context.Context, args []string) error {
+	return dispatch(ctx, "stonksctl symbols", symbolCommands, args)
+}
+
+func runSources(ctx context.Context, args []string) error {
+	return dispatch(ctx, "stonksctl sources", sourceCommands, args)
+}
+
+func runSymbolsList(ctx context.Context, args []string) error {
+	if err := parseFlags(flag.NewFlagSet("symbols list", flag.ContinueOnError), args, 0, ""); err != nil {
+		return err
+	}
+	appContext, err := appContext()
+	if err != nil {
+		return err
+	}
+	symbols, err := appContext.Deps.SymbolService.Symbols(ctx)
+	if err != nil {
+		return err
+	}
+	if symbols == nil {
+		symbols = []core.SymbolDetails{}
+	}
+	return printJSON(symbols)
+}
+
+func runSymbolsAdd(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("symbols add", flag.ContinueOnError)
+	name := flags.String("name", "", "display name")
+	exchangeID := flags.String("exchange", "", "exchange the symbol trades on, such as XETR")
+	inactive := flags.Bool("inactive", false, "add the symbol without scraping it")
+	if err := parseFlags(flags, args, 2, "<ticker> <isin>"); err != nil {
+		return err
+	}
+	appContext, err := appContext()
+	if err != nil {
+		return err
+	}
+	ticker, isin, active := flags.Arg(0), flags.Arg(1), !*inactive
+	symbol, err := appContext.Deps.SymbolService.CreateSymbol(ctx, core.SymbolInput{
+		Symbol:     &ticker,
+		ISIN:       &isin,
+		Name:       name,
+		Active:     &active,
+		ExchangeID: exchangeID,
+	})
+	if err != nil {
+		return err
+	}
+	return printJSON(symbol)
+}
+
+func runSourcesList(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("sources list", flag.ContinueOnError)
+	symbol := flags.String("symbol", "", "id of the symbol whose sources to list")
+	if err := parseFlags(flags, args, 0, ""); err != nil {
+		return err
+	}
+	appContext, err := appContext()
+	if err != nil {
+		return err
+	}
+	if *symbol == "" {
+		sources, err := appContext.Deps.SymbolService.Sources(ctx)
+		if err != nil {
+			return err
+		}
+		if sources == nil {
+			sources = []core.ScrapingSource{}
+		}
+		return printJSON(sources)
+	}
+	symbolID, err := parseID(*symbol)
+	if err != nil {
+		return err
+	}
+	sources, err := appContext.Deps.SymbolService.SymbolSources(ctx, symbolID)
+	if err != nil {
+		return err
+	}
+	if sources == nil {
+		sources = []core.SymbolSource{}
+	}
+	return printJSON(sources)
+}
+
+func runSourcesAdd(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("sources add", flag.ContinueOnError)
+	additionalInfo := flags.String("info", "", "additional info, such as an API key")
+	maxConcurrency := flags.Int("max-concurrency", 1, "requests to the source in flight at once")
+	requestsPerSecond := flags.Float64("rps", 0, "requests per second the source allows, 0 for no limit")
+	if err := parseFlags(flags, args, 3, "<id> <name> <base url>"); err != nil {
+		return err
+	}
+	appContext, err := appContext()
+	if err != nil {
+		return err
+	}
+	id, name, baseURL := flags.Arg(0), flags.Arg(1), flags.Arg(2)
+	source, err := appContext.Deps.SymbolService.CreateSource(ctx, core.ScrapingSourceInput{
+		ID:                &id,
+		Name:              &name,
+		BaseURL:           &baseURL,
+		AdditionalInfo:    additionalInfo,
+		MaxConcurrency:    maxConcurrency,
+		RequestsPerSecond: requestsPerSecond,
+	})
+	if err != nil {
+		return err
+	}
+	return printJSON(source)
+}
+
+func runSourcesAttach(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("sources attach", flag.ContinueOnError)
+	priority := flags.Int("priority", 0, "priority among the symbol's sources, lowest first")
+	if err := parseFlags(flags, args, 3, "<symbol id> <source id> <scrape url>"); err != nil {
+		return err
+	}
+	symbolID, err := parseID(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	appContext, err := appContext()
+	if err != nil {
+		return err
+	}
+	sourceID, scrapeURL := flags.Arg(1), flags.Arg(2)
+	source, err := appContext.Deps.SymbolService.CreateSymbolSource(ctx, symbolID, core.SymbolSourceInput{
+		SourceID:  &sourceID,
+		ScrapeURL: &scrapeURL,
+		Priority:  priority,
+	})
+	if err != nil {
+		return err
+	}
+	return printJSON(source)
+}
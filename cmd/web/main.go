@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -17,6 +18,7 @@ import (
 	"github.com/bjarke-xyz/stonks/internal/core"
 	"github.com/bjarke-xyz/stonks/internal/logging"
 	"github.com/bjarke-xyz/stonks/internal/repository/db"
+	"github.com/bjarke-xyz/stonks/internal/scheduler"
 	"github.com/bjarke-xyz/stonks/internal/web"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -25,8 +27,8 @@ func main() {
 	logging.Setup()
 
 	// Create a context that will be canceled when we receive a shutdown signal
-	// ctx, cancel := context.WithCancel(context.Background())
-	// defer cancel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	// Channel to listen for OS signals
 	stop := make(chan os.Signal, 1)
@@ -42,16 +44,29 @@ func main() {
 		slog.Error("opening db failed", "error", err)
 	}
 	if dbConn != nil {
-		err = db.Migrate("up", dbConn)
+		err = db.Migrate("up", dbConn, 0)
 		if err != nil {
 			slog.Error("migration failed", "error", err)
 		}
 	}
+	if cfg.MigrateStrict {
+		if err := checkSchema(ctx, dbConn, err); err != nil {
+			slog.Error("refusing to start, MIGRATE_STRICT is set", "error", err)
+			os.Exit(1)
+		}
+	}
 
 	appContext := app.AppContext(cfg)
 
 	runMetricsServer()
 
+	scrapeScheduler, err := scheduler.NewScheduler(appContext)
+	if err != nil {
+		slog.Error("creating scrape scheduler failed", "error", err)
+		os.Exit(1)
+	}
+	scrapeScheduler.Start(ctx)
+
 	srv := Server(appContext)
 	go func() {
 		slog.Info("listening", "addr", srv.Addr, "url", "http://localhost"+srv.Addr)
@@ -65,13 +80,16 @@ func main() {
 	<-stop
 	slog.Info("shutting down server")
 
-	// Cancel the context to signal all handlers that the server is shutting down
-	// cancel()
+	// Cancel the context to signal the scheduler that the server is shutting down
+	cancel()
 
 	// Create a context with a timeout for the server shutdown
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
 
+	// A pass in progress sees the canceled context and winds down.
+	scrapeScheduler.Wait(shutdownCtx)
+
 	// Shutdown the server gracefully
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		slog.Error("server shutdown failed", "error", err)
@@ -90,7 +108,7 @@ func Server(appContext *core.AppContext) *http.Server {
 
 func routes(appContext *core.AppContext) http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("GET /health", handleHealth)
+	mux.HandleFunc("GET /health", handleHealth(appContext))
 
 	apiHandlers := api.NewAPI(appContext)
 	apiHandlers.Route(mux)
@@ -102,15 +120,63 @@ func routes(appContext *core.AppContext) http.Handler {
 	return recovery(requestLog(mux))
 }
 
-func handleHealth(w http.ResponseWriter, r *http.Request) {
-	body, err := json.Marshal(map[string]string{"status": "ok"})
+// checkSchema fails if the database could not be opened or migrated up, given
+// as err, or if migrations are still pending.
+func checkSchema(ctx context.Context, dbConn *sql.DB, err error) error {
+	if dbConn == nil || err != nil {
+		return err
+	}
+	version, err := db.GetSchemaVersion(ctx, dbConn)
+	if err != nil {
+		return err
+	}
+	if version.Pending() {
+		return fmt.Errorf("schema is at version %v, migrations up to %v are pending", version.Current, version.Latest)
+	}
+	return nil
+}
+
+type health struct {
+	Status string            `json:"status"`
+	Schema *db.SchemaVersion `json:"schema,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// handleHealth reports the schema version alongside the status. It answers
+// 503 if the database cannot be read, and "degraded" while migrations are
+// pending.
+func handleHealth(appContext *core.AppContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusOK
+		h := health{Status: "ok"}
+		version, err := schemaVersion(r.Context(), appContext)
+		if err != nil {
+			slog.Warn("getting schema version for health check failed", "error", err)
+			status = http.StatusServiceUnavailable
+			h = health{Status: "unavailable", Error: err.Error()}
+		} else {
+			h.Schema = &version
+			if version.Pending() {
+				h.Status = "degraded"
+			}
+		}
+		body, err := json.Marshal(h)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		w.Write(body)
+	}
+}
+
+func schemaVersion(ctx context.Context, appContext *core.AppContext) (db.SchemaVersion, error) {
+	dbConn, err := db.Open(appContext.Config)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return db.SchemaVersion{}, err
 	}
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	w.Write(body)
+	return db.GetSchemaVersion(ctx, dbConn)
 }
 
 func runMetricsServer() {
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjarke-xyz/stonks/internal/config"
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+)
+
+// newTestDB opens a database in a temporary directory, migrated up to
+// version, or fully with version 0.
+func newTestDB(t *testing.T, version int64) (*config.Config, *sql.DB) {
+	t.Helper()
+	cfg := &config.Config{DbConnStr: filepath.Join(t.TempDir(), "stonks.db")}
+	conn, err := db.Open(cfg)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	direction := "up"
+	if version != 0 {
+		direction = "up-to"
+	}
+	if err := db.Migrate(direction, conn, version); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return cfg, conn
+}
+
+func TestCheckSchema(t *testing.T) {
+	ctx := context.Background()
+	_, migrated := newTestDB(t, 0)
+	_, pending := newTestDB(t, 3)
+	migrateErr := errors.New("migration failed")
+
+	tests := []struct {
+		name    string
+		conn    *sql.DB
+		err     error
+		wantErr bool
+	}{
+		{"fully migrated", migrated, nil, false},
+		{"pending migrations", pending, nil, true},
+		{"migration failed", migrated, migrateErr, true},
+		{"no database", nil, migrateErr, true},
+	}
+	for _, tt := range tests {
+		err := checkSchema(ctx, tt.conn, tt.err)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: got %v, want an error %v", tt.name, err, tt.wantErr)
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%v: got %v, want the migration error", tt.name, err)
+		}
+	}
+}
+
+func TestHealth(t *testing.T) {
+	migrated, _ := newTestDB(t, 0)
+	pending, _ := newTestDB(t, 3)
+	unreadable := &config.Config{DbConnStr: filepath.Join(t.TempDir(), "missing", "stonks.db")}
+
+	tests := []struct {
+		name        string
+		cfg         *config.Config
+		wantCode    int
+		wantStatus  string
+		wantCurrent bool // whether schema.current equals schema.latest
+	}{
+		{"fully migrated", migrated, http.StatusOK, "ok", true},
+		{"pending migrations", pending, http.StatusOK, "degraded", false},
+		{"unreadable database", unreadable, http.StatusServiceUnavailable, "unavailable", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handleHealth(&core.AppContext{Config: tt.cfg}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+			if rec.Code != tt.wantCode {
+				t.Errorf("code = %v, want %v", rec.Code, tt.wantCode)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+				t.Errorf("Content-Type = %q", got)
+			}
+			var h health
+			if err := json.Unmarshal(rec.Body.Bytes(), &h); err != nil {
+				t.Fatalf("decoding %q: %v", rec.Body.String(), err)
+			}
+			if h.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", h.Status, tt.wantStatus)
+			}
+			if tt.wantCode != http.StatusOK {
+				if h.Schema != nil || h.Error == "" {
+					t.Errorf("got schema %+v and error %q, want only an error", h.Schema, h.Error)
+				}
+				return
+			}
+			if h.Schema == nil {
+				t.Fatal("no schema version")
+			}
+			if current := h.Schema.Current == h.Schema.Latest; current != tt.wantCurrent {
+				t.Errorf("schema %+v, want current == latest to be %v", *h.Schema, tt.wantCurrent)
+			}
+			if !tt.wantCurrent && h.Schema.Current != 3 {
+				t.Errorf("schema.current = %v, want 3", h.Schema.Current)
+			}
+		})
+	}
+}
@@ -2,12 +2,20 @@ package api
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/bjarke-xyz/stonks/internal/core"
 )
 
+// defaultRunsLimit is how many runs GET /api/jobs lists without ?limit.
+const defaultRunsLimit = 20
+
 type api struct {
 	appContext *core.AppContext
 }
@@ -19,15 +27,39 @@ func NewAPI(appContext *core.AppContext) *api {
 }
 
 func (a *api) Route(mux *http.ServeMux) {
-	mux.HandleFunc("POST /api/job", a.RunJob())
+	mux.HandleFunc("POST /api/job", a.authorized(a.RunJob()))
+	mux.HandleFunc("GET /api/job/{id}", a.authorized(a.GetJob()))
+	mux.HandleFunc("GET /api/jobs", a.authorized(a.GetJobs()))
+	mux.HandleFunc("GET /api/quarantine", a.authorized(a.GetQuarantine()))
+	mux.HandleFunc("POST /api/quarantine/{id}/approve", a.authorized(a.ReviewQuarantine(true)))
+	mux.HandleFunc("POST /api/quarantine/{id}/reject", a.authorized(a.ReviewQuarantine(false)))
+	mux.HandleFunc("POST /api/bars/rebuild", a.authorized(a.RebuildBars()))
+	mux.HandleFunc("POST /api/retention", a.authorized(a.ApplyRetention()))
+	a.routeSymbols(mux)
 }
 
-func (a *api) RunJob() http.HandlerFunc {
+// authorized rejects requests that do not carry the job key.
+func (a *api) authorized(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("Authorization") != a.appContext.Config.JobKey {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
+		next(w, r)
+	}
+}
+
+// RunJob starts a scrape run. It answers 202 with the run ID in
+// fire-and-forget mode, and otherwise waits and answers with the finished run:
+// 200 on success, 409 if another run was in progress, 500 if it failed.
+func (a *api) RunJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scraperService := a.appContext.Deps.ScraperService
+		runID, err := scraperService.NewRun(r.Context(), core.ScrapeTriggerAPI)
+		if err != nil {
+			a.writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
 
 		fireAndForget := r.URL.Query().Get("fireAndForget") == "true"
 
@@ -36,11 +68,160 @@ func (a *api) RunJob() http.HandlerFunc {
 			ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
 			go func() {
 				defer cancel()
-				a.appContext.Deps.ScraperService.ScrapeSymbols(ctx)
+				_ = scraperService.ScrapeSymbols(ctx, runID)
 			}()
-		} else {
-			a.appContext.Deps.ScraperService.ScrapeSymbols(r.Context())
+			writeJSON(w, http.StatusAccepted, map[string]int64{"id": runID})
+			return
+		}
+
+		status := http.StatusOK
+		if err := scraperService.ScrapeSymbols(r.Context(), runID); errors.Is(err, core.ErrScrapeInProgress) {
+			status = http.StatusConflict
+		} else if err != nil {
+			status = http.StatusInternalServerError
+		}
+		run, err := scraperService.Run(r.Context(), runID)
+		if err != nil {
+			a.writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, status, run)
+	}
+}
+
+// RebuildBars recomputes every symbol's OHLC bars from its stored prices, for
+// prices stored before bars were kept or changed outside of scraping. Bars
+// older than a symbol's downsampling cutoff are kept.
+func (a *api) RebuildBars() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symbols, err := a.appContext.Deps.QuoteService.RebuildBars(r.Context())
+		if err != nil {
+			a.writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int{"symbols": symbols})
+	}
+}
+
+// ApplyRetention runs the price retention job now, rather than waiting for the
+// scheduler. With ?dryRun=true it only reports what it would remove.
+func (a *api) ApplyRetention() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dryRun := r.URL.Query().Get("dryRun") == "true"
+		report, err := a.appContext.Deps.RetentionService.ApplyRetention(r.Context(), dryRun)
+		if errors.Is(err, core.ErrRetentionDisabled) {
+			a.writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		if err != nil {
+			a.writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+func (a *api) GetJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		runID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			a.writeError(w, r, http.StatusBadRequest, err)
+			return
 		}
-		w.WriteHeader(http.StatusOK)
+		run, err := a.appContext.Deps.ScraperService.Run(r.Context(), runID)
+		if errors.Is(err, sql.ErrNoRows) {
+			a.writeError(w, r, http.StatusNotFound, err)
+			return
+		}
+		if err != nil {
+			a.writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, run)
+	}
+}
+
+func (a *api) GetJobs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultRunsLimit
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsed, err := strconv.Atoi(limitStr)
+			if err != nil || parsed <= 0 {
+				a.writeError(w, r, http.StatusBadRequest, errors.New("limit must be a positive integer"))
+				return
+			}
+			limit = parsed
+		}
+		runs, err := a.appContext.Deps.ScraperService.Runs(r.Context(), limit)
+		if err != nil {
+			a.writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if runs == nil {
+			runs = []core.ScrapeRun{}
+		}
+		writeJSON(w, http.StatusOK, runs)
+	}
+}
+
+// GetQuarantine lists quarantined prices, optionally filtered by ?status.
+func (a *api) GetQuarantine() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		quarantined, err := a.appContext.Deps.ScraperService.QuarantinedPrices(r.Context(), r.URL.Query().Get("status"))
+		if err != nil {
+			a.writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		if quarantined == nil {
+			quarantined = []core.QuarantinedPrice{}
+		}
+		writeJSON(w, http.StatusOK, quarantined)
+	}
+}
+
+// ReviewQuarantine approves or rejects a quarantined price. It answers 409 if
+// the price was already reviewed.
+func (a *api) ReviewQuarantine(approve bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			a.writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		scraperService := a.appContext.Deps.ScraperService
+		review := scraperService.RejectQuarantinedPrice
+		if approve {
+			review = scraperService.ApproveQuarantinedPrice
+		}
+		quarantined, err := review(r.Context(), id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			a.writeError(w, r, http.StatusNotFound, err)
+		case errors.Is(err, core.ErrNotPending):
+			a.writeError(w, r, http.StatusConflict, err)
+		case err != nil:
+			a.writeError(w, r, http.StatusInternalServerError, err)
+		default:
+			writeJSON(w, http.StatusOK, quarantined)
+		}
+	}
+}
+
+func (a *api) writeError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		slog.Error("handler error", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		slog.Error("encoding json response failed", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(body)
 }
@@ -0,0 +1,347 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/importer"
+)
+
+// maxBodyBytes bounds the JSON bodies of the admin endpoints.
+const maxBodyBytes = 1 << 20
+
+// maxImportBytes bounds CSV price imports, which hold years of prices.
+const maxImportBytes = 32 << 20
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+func (a *api) routeSymbols(mux *http.ServeMux) {
+	// Search only reads what the index page shows anyway, so it needs no key.
+	mux.HandleFunc("GET /api/symbols/search", a.SearchSymbols())
+	mux.HandleFunc("GET /api/symbols", a.authorized(a.GetSymbols()))
+	mux.HandleFunc("POST /api/symbols", a.authorized(a.CreateSymbol()))
+	mux.HandleFunc("GET /api/symbols/{id}", a.authorized(a.GetSymbol()))
+	mux.HandleFunc("PATCH /api/symbols/{id}", a.authorized(a.UpdateSymbol()))
+	mux.HandleFunc("DELETE /api/symbols/{id}", a.authorized(a.DeleteSymbol()))
+
+	mux.HandleFunc("GET /api/symbols/{id}/sources", a.authorized(a.GetSymbolSources()))
+	mux.HandleFunc("POST /api/symbols/{id}/sources", a.authorized(a.CreateSymbolSource()))
+	mux.HandleFunc("PATCH /api/symbols/{id}/sources/{symbolSourceId}", a.authorized(a.UpdateSymbolSource()))
+	mux.HandleFunc("DELETE /api/symbols/{id}/sources/{symbolSourceId}", a.authorized(a.DeleteSymbolSource()))
+
+	mux.HandleFunc("POST /api/symbols/{id}/backfill", a.authorized(a.BackfillSymbol()))
+	mux.HandleFunc("POST /api/symbols/{symbol}/prices/import", a.authorized(a.ImportPrices()))
+
+	mux.HandleFunc("GET /api/exchanges", a.authorized(a.GetExchanges()))
+
+	mux.HandleFunc("GET /api/sources", a.authorized(a.GetSources()))
+	mux.HandleFunc("POST /api/sources", a.authorized(a.CreateSource()))
+	mux.HandleFunc("GET /api/sources/{id}", a.authorized(a.GetSource()))
+	mux.HandleFunc("PATCH /api/sources/{id}", a.authorized(a.UpdateSource()))
+	mux.HandleFunc("DELETE /api/sources/{id}", a.authorized(a.DeleteSource()))
+}
+
+func (a *api) GetSymbols() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symbols, err := a.appContext.Deps.SymbolService.Symbols(r.Context())
+		if symbols == nil {
+			symbols = []core.SymbolDetails{}
+		}
+		a.writeResult(w, r, http.StatusOK, symbols, err)
+	}
+}
+
+// SearchSymbols finds symbols by ?q, matching the start of words in their
+// ticker, name or ISIN. ?limit caps the results.
+func (a *api) SearchSymbols() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultSearchLimit
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsed, err := strconv.Atoi(limitStr)
+			if err != nil || parsed <= 0 || parsed > maxSearchLimit {
+				a.writeError(w, r, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %v", maxSearchLimit))
+				return
+			}
+			limit = parsed
+		}
+		symbols, err := a.appContext.Deps.SymbolService.SearchSymbols(r.Context(), r.URL.Query().Get("q"), limit)
+		if symbols == nil {
+			symbols = []core.SymbolDetails{}
+		}
+		a.writeResult(w, r, http.StatusOK, symbols, err)
+	}
+}
+
+func (a *api) GetSymbol() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := a.int64PathValue(w, r, "id")
+		if !ok {
+			return
+		}
+		symbol, err := a.appContext.Deps.SymbolService.Symbol(r.Context(), id)
+		a.writeResult(w, r, http.StatusOK, symbol, err)
+	}
+}
+
+func (a *api) CreateSymbol() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input core.SymbolInput
+		if !a.decodeJSON(w, r, &input) {
+			return
+		}
+		symbol, err := a.appContext.Deps.SymbolService.CreateSymbol(r.Context(), input)
+		a.writeResult(w, r, http.StatusCreated, symbol, err)
+	}
+}
+
+func (a *api) UpdateSymbol() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := a.int64PathValue(w, r, "id")
+		if !ok {
+			return
+		}
+		var input core.SymbolInput
+		if !a.decodeJSON(w, r, &input) {
+			return
+		}
+		symbol, err := a.appContext.Deps.SymbolService.UpdateSymbol(r.Context(), id, input)
+		a.writeResult(w, r, http.StatusOK, symbol, err)
+	}
+}
+
+func (a *api) DeleteSymbol() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := a.int64PathValue(w, r, "id")
+		if !ok {
+			return
+		}
+		err := a.appContext.Deps.SymbolService.DeleteSymbol(r.Context(), id)
+		a.writeResult(w, r, http.StatusNoContent, nil, err)
+	}
+}
+
+func (a *api) GetSymbolSources() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := a.int64PathValue(w, r, "id")
+		if !ok {
+			return
+		}
+		sources, err := a.appContext.Deps.SymbolService.SymbolSources(r.Context(), id)
+		if sources == nil {
+			sources = []core.SymbolSource{}
+		}
+		a.writeResult(w, r, http.StatusOK, sources, err)
+	}
+}
+
+func (a *api) CreateSymbolSource() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := a.int64PathValue(w, r, "id")
+		if !ok {
+			return
+		}
+		var input core.SymbolSourceInput
+		if !a.decodeJSON(w, r, &input) {
+			return
+		}
+		source, err := a.appContext.Deps.SymbolService.CreateSymbolSource(r.Context(), id, input)
+		a.writeResult(w, r, http.StatusCreated, source, err)
+	}
+}
+
+// BackfillSymbol fetches and stores a range of the symbol's past prices. It
+// answers once they are stored, with how many were new.
+func (a *api) BackfillSymbol() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := a.int64PathValue(w, r, "id")
+		if !ok {
+			return
+		}
+		var input core.BackfillInput
+		if !a.decodeJSON(w, r, &input) {
+			return
+		}
+		result, err := a.appContext.Deps.ScraperService.Backfill(r.Context(), id, input)
+		a.writeResult(w, r, http.StatusOK, result, err)
+	}
+}
+
+// ImportPrices stores the prices of a CSV body for the symbol, given by ticker
+// or ISIN. The query maps the columns: ?timestampColumn, ?priceColumn and
+// ?currencyColumn name them, ?currency fixes the currency of every row,
+// ?delimiter, ?decimal and ?timestampFormat describe the format and ?timezone
+// is that of timestamps without an offset.
+func (a *api) ImportPrices() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		delimiter, err := importer.ParseDelimiter(query.Get("delimiter"))
+		if err != nil {
+			a.writeError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		location := time.UTC
+		if timezone := query.Get("timezone"); timezone != "" {
+			if location, err = time.LoadLocation(timezone); err != nil {
+				a.writeError(w, r, http.StatusBadRequest, fmt.Errorf("invalid timezone %q: %w", timezone, err))
+				return
+			}
+		}
+		options := core.ImportOptions{
+			Delimiter:       delimiter,
+			Decimal:         query.Get("decimal"),
+			TimestampColumn: query.Get("timestampColumn"),
+			PriceColumn:     query.Get("priceColumn"),
+			CurrencyColumn:  query.Get("currencyColumn"),
+			Currency:        query.Get("currency"),
+			TimestampFormat: query.Get("timestampFormat"),
+			Location:        location,
+		}
+		body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+		result, err := a.appContext.Deps.ImportService.ImportPrices(r.Context(), r.PathValue("symbol"), body, options)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			a.writeError(w, r, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		a.writeResult(w, r, http.StatusOK, result, err)
+	}
+}
+
+func (a *api) UpdateSymbolSource() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := a.int64PathValue(w, r, "id")
+		if !ok {
+			return
+		}
+		symbolSourceID, ok := a.int64PathValue(w, r, "symbolSourceId")
+		if !ok {
+			return
+		}
+		var input core.SymbolSourceInput
+		if !a.decodeJSON(w, r, &input) {
+			return
+		}
+		source, err := a.appContext.Deps.SymbolService.UpdateSymbolSource(r.Context(), id, symbolSourceID, input)
+		a.writeResult(w, r, http.StatusOK, source, err)
+	}
+}
+
+func (a *api) DeleteSymbolSource() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := a.int64PathValue(w, r, "id")
+		if !ok {
+			return
+		}
+		symbolSourceID, ok := a.int64PathValue(w, r, "symbolSourceId")
+		if !ok {
+			return
+		}
+		err := a.appContext.Deps.SymbolService.DeleteSymbolSource(r.Context(), id, symbolSourceID)
+		a.writeResult(w, r, http.StatusNoContent, nil, err)
+	}
+}
+
+func (a *api) GetExchanges() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		exchanges, err := a.appContext.Deps.SymbolService.Exchanges(r.Context())
+		if exchanges == nil {
+			exchanges = []core.Exchange{}
+		}
+		a.writeResult(w, r, http.StatusOK, exchanges, err)
+	}
+}
+
+func (a *api) GetSources() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sources, err := a.appContext.Deps.SymbolService.Sources(r.Context())
+		if sources == nil {
+			sources = []core.ScrapingSource{}
+		}
+		a.writeResult(w, r, http.StatusOK, sources, err)
+	}
+}
+
+func (a *api) GetSource() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		source, err := a.appContext.Deps.SymbolService.Source(r.Context(), r.PathValue("id"))
+		a.writeResult(w, r, http.StatusOK, source, err)
+	}
+}
+
+func (a *api) CreateSource() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input core.ScrapingSourceInput
+		if !a.decodeJSON(w, r, &input) {
+			return
+		}
+		source, err := a.appContext.Deps.SymbolService.CreateSource(r.Context(), input)
+		a.writeResult(w, r, http.StatusCreated, source, err)
+	}
+}
+
+func (a *api) UpdateSource() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input core.ScrapingSourceInput
+		if !a.decodeJSON(w, r, &input) {
+			return
+		}
+		source, err := a.appContext.Deps.SymbolService.UpdateSource(r.Context(), r.PathValue("id"), input)
+		a.writeResult(w, r, http.StatusOK, source, err)
+	}
+}
+
+func (a *api) DeleteSource() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := a.appContext.Deps.SymbolService.DeleteSource(r.Context(), r.PathValue("id"))
+		a.writeResult(w, r, http.StatusNoContent, nil, err)
+	}
+}
+
+// writeResult writes data with status, or the error a SymbolService returned:
+// 404 for a missing record, 400 for invalid input and 409 for a clash.
+func (a *api) writeResult(w http.ResponseWriter, r *http.Request, status int, data any, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		a.writeError(w, r, http.StatusNotFound, err)
+	case errors.Is(err, core.ErrInvalidInput):
+		a.writeError(w, r, http.StatusBadRequest, err)
+	case errors.Is(err, core.ErrAlreadyExists):
+		a.writeError(w, r, http.StatusConflict, err)
+	case err != nil:
+		a.writeError(w, r, http.StatusInternalServerError, err)
+	case status == http.StatusNoContent:
+		w.WriteHeader(status)
+	default:
+		writeJSON(w, status, data)
+	}
+}
+
+// decodeJSON decodes the request body into v, answering 400 if it cannot.
+// Unknown fields are rejected so a misspelt one is not silently ignored.
+func (a *api) decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		a.writeError(w, r, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+func (a *api) int64PathValue(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	value, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil {
+		a.writeError(w, r, http.StatusBadRequest, fmt.Errorf("invalid %v: %w", name, err))
+		return 0, false
+	}
+	return value, true
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bjarke-xyz/stonks/internal/app"
+	"github.com/bjarke-xyz/stonks/internal/config"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+)
+
+const testJobKey = "key"
+
+func newTestServer(t *testing.T) *http.ServeMux {
+	t.Helper()
+	cfg := &config.Config{DbConnStr: filepath.Join(t.TempDir(), "stonks.db"), JobKey: testJobKey}
+	conn, err := db.Open(cfg)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.Migrate("up", conn, 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	mux := http.NewServeMux()
+	NewAPI(app.AppContext(cfg)).Route(mux)
+	return mux
+}
+
+// do sends an authorized request and returns the status and body.
+func do(t *testing.T, mux *http.ServeMux, method string, target string, body string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", testJobKey)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+func TestSymbolsAPI(t *testing.T) {
+	mux := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/symbols", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("without the key: got %v, want 401", rec.Code)
+	}
+
+	status, body := do(t, mux, http.MethodPost, "/api/symbols", `{"symbol": "aapl", "isin": "US0378331005", "name": "Apple"}`)
+	if status != http.StatusCreated {
+		t.Fatalf("create: got %v %v, want 201", status, body)
+	}
+	var symbol struct {
+		ID     int64  `json:"id"`
+		Symbol string `json:"symbol"`
+	}
+	if err := json.Unmarshal([]byte(body), &symbol); err != nil || symbol.Symbol != "AAPL" {
+		t.Fatalf("create: got %v (%v), want AAPL", body, err)
+	}
+	status, body = do(t, mux, http.MethodPost, "/api/sources", `{"id": "YFINANCEAPI", "name": "yfinance", "baseUrl": "https://example.com"}`)
+	if status != http.StatusCreated {
+		t.Fatalf("create source: got %v %v, want 201", status, body)
+	}
+	status, body = do(t, mux, http.MethodPost, fmt.Sprintf("/api/symbols/%v/sources", symbol.ID), `{"sourceId": "YFINANCEAPI", "scrapeUrl": "https://example.com/AAPL"}`)
+	if status != http.StatusCreated {
+		t.Fatalf("attach source: got %v %v, want 201", status, body)
+	}
+	var symbolSource struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal([]byte(body), &symbolSource); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"duplicate ticker", http.MethodPost, "/api/symbols", `{"symbol": "AAPL", "isin": "US0378331005"}`, http.StatusConflict},
+		{"invalid isin", http.MethodPost, "/api/symbols", `{"symbol": "SAP", "isin": "DE0007164601"}`, http.StatusBadRequest},
+		{"unknown field", http.MethodPost, "/api/symbols", `{"ticker": "SAP"}`, http.StatusBadRequest},
+		{"unknown symbol", http.MethodGet, "/api/symbols/999", "", http.StatusNotFound},
+		{"invalid id", http.MethodGet, "/api/symbols/abc", "", http.StatusBadRequest},
+		{"duplicate source", http.MethodPost, "/api/sources", `{"id": "YFINANCEAPI", "name": "yfinance", "baseUrl": "https://example.com"}`, http.StatusConflict},
+		{"source without scraper", http.MethodPost, "/api/sources", `{"id": "NOPE", "name": "nope", "baseUrl": "https://example.com"}`, http.StatusBadRequest},
+		{"symbol source of another symbol", http.MethodDelete, fmt.Sprintf("/api/symbols/999/sources/%v", symbolSource.ID), "", http.StatusNotFound},
+		{"delete symbol source", http.MethodDelete, fmt.Sprintf("/api/symbols/%v/sources/%v", symbol.ID, symbolSource.ID), "", http.StatusNoContent},
+		{"delete symbol source twice", http.MethodDelete, fmt.Sprintf("/api/symbols/%v/sources/%v", symbol.ID, symbolSource.ID), "", http.StatusNotFound},
+		{"delete symbol", http.MethodDelete, fmt.Sprintf("/api/symbols/%v", symbol.ID), "", http.StatusNoContent},
+		{"delete symbol twice", http.MethodDelete, fmt.Sprintf("/api/symbols/%v", symbol.ID), "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		status, body := do(t, mux, tt.method, tt.target, tt.body)
+		if status != tt.want {
+			t.Errorf("%v: got %v %v, want %v", tt.name, status, body, tt.want)
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package app
 
 import (
+	"github.com/bjarke-xyz/stonks/internal/calendar"
 	"github.com/bjarke-xyz/stonks/internal/config"
 	"github.com/bjarke-xyz/stonks/internal/core"
 	"github.com/bjarke-xyz/stonks/internal/currency"
+	"github.com/bjarke-xyz/stonks/internal/importer"
 	"github.com/bjarke-xyz/stonks/internal/quote"
 	"github.com/bjarke-xyz/stonks/internal/repository"
+	"github.com/bjarke-xyz/stonks/internal/retention"
 	"github.com/bjarke-xyz/stonks/internal/scrapers"
+	"github.com/bjarke-xyz/stonks/internal/symbols"
 )
 
 func AppContext(cfg *config.Config) *core.AppContext {
@@ -20,6 +24,10 @@ func AppContext(cfg *config.Config) *core.AppContext {
 		QuoteService:        quote.NewQuoteService(appContext),
 		ExchangeRateService: currency.NewExchangeRateService(appContext),
 		CurrencyService:     currency.NewCurrencyService(appContext),
+		SymbolService:       symbols.NewSymbolService(appContext),
+		CalendarService:     calendar.NewCalendarService(),
+		RetentionService:    retention.NewRetentionService(appContext),
+		ImportService:       importer.NewImportService(appContext),
 	}
 	appContext.Deps = deps
 
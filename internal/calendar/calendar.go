@@ -0,0 +1,161 @@
+package calendar
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+)
+
+// calendars.json holds the session hours and holidays of the exchanges seeded
+// in the exchanges table. Holidays must be added by hand for each new year;
+// past the last listed year every weekday counts as a trading day.
+//
+//go:embed calendars.json
+var calendarsJSON []byte
+
+// Calendar is the regular trading session of one exchange. Early closes are
+// not modelled.
+type Calendar struct {
+	ExchangeID string
+	Location   *time.Location
+	Open       time.Duration // since midnight
+	Close      time.Duration // since midnight, exclusive
+	Holidays   map[string]bool
+}
+
+type calendarFile struct {
+	Exchanges []struct {
+		ID       string   `json:"id"`
+		Timezone string   `json:"timezone"`
+		Open     string   `json:"open"`
+		Close    string   `json:"close"`
+		Holidays []string `json:"holidays"`
+	} `json:"exchanges"`
+}
+
+// Parse parses calendars in the format of calendars.json, keyed by exchange ID.
+func Parse(data []byte) (map[string]*Calendar, error) {
+	var file calendarFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error decoding calendars: %w", err)
+	}
+	calendars := make(map[string]*Calendar, len(file.Exchanges))
+	for _, e := range file.Exchanges {
+		loc, err := time.LoadLocation(e.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone of %v: %w", e.ID, err)
+		}
+		open, err := parseClock(e.Open)
+		if err != nil {
+			return nil, fmt.Errorf("invalid open of %v: %w", e.ID, err)
+		}
+		closeAt, err := parseClock(e.Close)
+		if err != nil {
+			return nil, fmt.Errorf("invalid close of %v: %w", e.ID, err)
+		}
+		if closeAt <= open {
+			return nil, fmt.Errorf("close of %v is not after open", e.ID)
+		}
+		holidays := make(map[string]bool, len(e.Holidays))
+		for _, h := range e.Holidays {
+			if _, err := time.Parse(time.DateOnly, h); err != nil {
+				return nil, fmt.Errorf("invalid holiday of %v: %w", e.ID, err)
+			}
+			holidays[h] = true
+		}
+		calendars[e.ID] = &Calendar{
+			ExchangeID: e.ID,
+			Location:   loc,
+			Open:       open,
+			Close:      closeAt,
+			Holidays:   holidays,
+		}
+	}
+	return calendars, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// IsTradingDay reports whether the exchange trades on the day of t, in the
+// exchange's time zone.
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	local := t.In(c.Location)
+	if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+		return false
+	}
+	return !c.Holidays[local.Format(time.DateOnly)]
+}
+
+// IsOpen reports whether t falls inside a trading session.
+func (c *Calendar) IsOpen(t time.Time) bool {
+	if !c.IsTradingDay(t) {
+		return false
+	}
+	local := t.In(c.Location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.Location)
+	return !local.Before(c.at(midnight, c.Open)) && local.Before(c.at(midnight, c.Close))
+}
+
+// NextOpen is the first session open after t. It gives up after two weeks,
+// which no run of holidays comes close to.
+func (c *Calendar) NextOpen(t time.Time) (time.Time, bool) {
+	local := t.In(c.Location)
+	for i := range 14 {
+		day := time.Date(local.Year(), local.Month(), local.Day()+i, 0, 0, 0, 0, c.Location)
+		if !c.IsTradingDay(day) {
+			continue
+		}
+		if open := c.at(day, c.Open); open.After(t) {
+			return open, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// at is the wall clock time since midnight on day, which on daylight saving
+// days is not the same as adding the duration.
+func (c *Calendar) at(day time.Time, sinceMidnight time.Duration) time.Time {
+	h := int(sinceMidnight / time.Hour)
+	m := int(sinceMidnight % time.Hour / time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, c.Location)
+}
+
+type CalendarService struct {
+	calendars map[string]*Calendar
+}
+
+// NewCalendarService loads the bundled calendars. They ship with the binary,
+// so failing to parse them is a programming error.
+func NewCalendarService() core.CalendarService {
+	calendars, err := Parse(calendarsJSON)
+	if err != nil {
+		panic(err)
+	}
+	return &CalendarService{calendars: calendars}
+}
+
+// MarketStatus implements core.CalendarService.
+func (s *CalendarService) MarketStatus(exchangeID string, t time.Time) core.MarketStatus {
+	status := core.MarketStatus{ExchangeID: exchangeID, Open: true}
+	c, ok := s.calendars[exchangeID]
+	if !ok {
+		return status
+	}
+	status.Known = true
+	status.Open = c.IsOpen(t)
+	if !status.Open {
+		if next, ok := c.NextOpen(t); ok {
+			status.NextOpen = &next
+		}
+	}
+	return status
+}
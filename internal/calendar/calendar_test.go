@@ -0,0 +1,48 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarketStatus(t *testing.T) {
+	s := NewCalendarService()
+	tests := []struct {
+		name       string
+		exchangeID string
+		t          string
+		known      bool
+		open       bool
+		nextOpen   string
+	}{
+		{"xetra session", "XETR", "2026-10-19T10:00:00+02:00", true, true, ""},
+		{"xetra before open", "XETR", "2026-10-19T08:59:00+02:00", true, false, "2026-10-19T09:00:00+02:00"},
+		{"xetra at close", "XETR", "2026-10-19T17:30:00+02:00", true, false, "2026-10-20T09:00:00+02:00"},
+		{"xetra weekend", "XETR", "2026-10-17T12:00:00+02:00", true, false, "2026-10-19T09:00:00+02:00"},
+		{"xetra christmas", "XETR", "2026-12-23T18:00:00+01:00", true, false, "2026-12-28T09:00:00+01:00"},
+		// 15:00 in Berlin is 09:00 in New York, before the open.
+		{"nyse in its own time zone", "XNYS", "2026-10-19T15:00:00+02:00", true, false, "2026-10-19T09:30:00-04:00"},
+		{"nyse across daylight saving", "XNYS", "2026-11-01T12:00:00-05:00", true, false, "2026-11-02T09:30:00-05:00"},
+		{"unknown exchange", "XXXX", "2026-10-17T12:00:00Z", false, true, ""},
+		{"no exchange", "", "2026-10-17T12:00:00Z", false, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, _ := time.Parse(time.RFC3339, tt.t)
+			status := s.MarketStatus(tt.exchangeID, at)
+			if status.Known != tt.known || status.Open != tt.open {
+				t.Fatalf("got known %v open %v, want known %v open %v", status.Known, status.Open, tt.known, tt.open)
+			}
+			if tt.nextOpen == "" {
+				if status.NextOpen != nil {
+					t.Fatalf("got next open %v, want none", status.NextOpen)
+				}
+				return
+			}
+			want, _ := time.Parse(time.RFC3339, tt.nextOpen)
+			if status.NextOpen == nil || !status.NextOpen.Equal(want) {
+				t.Fatalf("got next open %v, want %v", status.NextOpen, want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/bjarke-xyz/stonks/pkg"
@@ -13,6 +14,9 @@ import (
 type Config struct {
 	Port      int
 	DbConnStr string
+	// MigrateStrict refuses to start the server when migrating the database
+	// fails or leaves migrations pending.
+	MigrateStrict bool
 
 	JobKey string
 
@@ -20,6 +24,38 @@ type Config struct {
 	BuildTime *time.Time
 
 	YFinanceAPIAuthKey string
+
+	// ECBBaseURL is where the eurofxref feeds are fetched from. Tests point it
+	// at a fixture server.
+	ECBBaseURL string
+
+	// ScrapeInterval is how often the built-in scheduler scrapes. Zero leaves
+	// scraping to POST /api/job.
+	ScrapeInterval time.Duration
+	// ScrapeWindows limits scheduled scraping to e.g. "Mon-Fri 08:00-22:00",
+	// in ScrapeTimezone. Several windows are separated by ";". Empty means
+	// around the clock.
+	ScrapeWindows  string
+	ScrapeTimezone *time.Location
+	// ScrapeMaxConsecutiveFailures deactivates a symbol source after this many
+	// failed scrapes in a row. Zero never deactivates.
+	ScrapeMaxConsecutiveFailures int
+	// ScrapeStaleAfter makes a scrape fall back to a symbol's next source when
+	// the price it got is older than this. Zero only falls back on failure.
+	ScrapeStaleAfter time.Duration
+	// ScrapeMaxDeviationPercent quarantines a scraped price that differs from
+	// the last stored one by more than this. Zero disables the check.
+	ScrapeMaxDeviationPercent float64
+	// ScrapeAfterClose keeps scraping a symbol this long after its exchange
+	// closes, to pick up the closing price. Symbols on a closed exchange are
+	// skipped otherwise.
+	ScrapeAfterClose time.Duration
+
+	// RetentionHourlyAfter downsamples prices older than this to the last
+	// price of each hour, and RetentionDailyAfter those older than it to the
+	// last of each day. Zero disables either step.
+	RetentionHourlyAfter time.Duration
+	RetentionDailyAfter  time.Duration
 }
 
 const (
@@ -27,13 +63,21 @@ const (
 	AppEnvProduction  = "production"
 )
 
+const (
+	defaultECBBaseURL                = "https://www.ecb.europa.eu/stats/eurofxref"
+	defaultScrapeMaxDeviationPercent = 20
+	defaultScrapeAfterClose          = 30 * time.Minute
+)
+
 func (c *Config) ConnectionString() string {
 	// _time_format=sqlite makes the driver write time.Time as
 	// "2006-01-02 15:04:05.999999999-07:00". Without it the driver defaults to
 	// time.Time.String(), which for an unnamed fixed zone renders the offset in
 	// the zone-name slot ("... +0200 +0200") — a value neither the driver nor
-	// SQLite's date functions can parse back.
-	return fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_time_format=sqlite", c.DbConnStr)
+	// SQLite's date functions can parse back. _timezone=UTC writes every
+	// time.Time with the same offset, so stored timestamps compare as text in
+	// time order.
+	return fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_time_format=sqlite&_timezone=UTC", c.DbConnStr)
 }
 
 func NewConfig() (*Config, error) {
@@ -55,12 +99,109 @@ func NewConfig() (*Config, error) {
 		}
 		buildTime = &_buildTime
 	}
+	var migrateStrict bool
+	if migrateStrictStr := os.Getenv("MIGRATE_STRICT"); migrateStrictStr != "" {
+		var err error
+		migrateStrict, err = strconv.ParseBool(migrateStrictStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate MIGRATE_STRICT: invalid value %q", migrateStrictStr)
+		}
+	}
+	var scrapeInterval time.Duration
+	if scrapeIntervalStr := os.Getenv("SCRAPE_INTERVAL"); scrapeIntervalStr != "" {
+		var err error
+		scrapeInterval, err = time.ParseDuration(scrapeIntervalStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate SCRAPE_INTERVAL: %w", err)
+		}
+	}
+	var scrapeMaxConsecutiveFailures int
+	if maxFailuresStr := os.Getenv("SCRAPE_MAX_CONSECUTIVE_FAILURES"); maxFailuresStr != "" {
+		var err error
+		scrapeMaxConsecutiveFailures, err = strconv.Atoi(maxFailuresStr)
+		if err != nil || scrapeMaxConsecutiveFailures < 0 {
+			return nil, fmt.Errorf("failed to validate SCRAPE_MAX_CONSECUTIVE_FAILURES: invalid value %q", maxFailuresStr)
+		}
+	}
+	var scrapeStaleAfter time.Duration
+	if staleAfterStr := os.Getenv("SCRAPE_STALE_AFTER"); staleAfterStr != "" {
+		var err error
+		scrapeStaleAfter, err = time.ParseDuration(staleAfterStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate SCRAPE_STALE_AFTER: %w", err)
+		}
+	}
+	scrapeMaxDeviationPercent := float64(defaultScrapeMaxDeviationPercent)
+	if maxDeviationStr := os.Getenv("SCRAPE_MAX_DEVIATION_PERCENT"); maxDeviationStr != "" {
+		var err error
+		scrapeMaxDeviationPercent, err = strconv.ParseFloat(maxDeviationStr, 64)
+		if err != nil || scrapeMaxDeviationPercent < 0 {
+			return nil, fmt.Errorf("failed to validate SCRAPE_MAX_DEVIATION_PERCENT: invalid value %q", maxDeviationStr)
+		}
+	}
+	scrapeAfterClose := defaultScrapeAfterClose
+	if afterCloseStr := os.Getenv("SCRAPE_AFTER_CLOSE"); afterCloseStr != "" {
+		var err error
+		scrapeAfterClose, err = time.ParseDuration(afterCloseStr)
+		if err != nil || scrapeAfterClose < 0 {
+			return nil, fmt.Errorf("failed to validate SCRAPE_AFTER_CLOSE: invalid value %q", afterCloseStr)
+		}
+	}
+	retentionHourlyAfter, err := parseRetention("RETENTION_HOURLY_AFTER")
+	if err != nil {
+		return nil, err
+	}
+	retentionDailyAfter, err := parseRetention("RETENTION_DAILY_AFTER")
+	if err != nil {
+		return nil, err
+	}
+	if retentionHourlyAfter > 0 && retentionDailyAfter > 0 && retentionDailyAfter <= retentionHourlyAfter {
+		return nil, fmt.Errorf("failed to validate RETENTION_DAILY_AFTER: must be longer than RETENTION_HOURLY_AFTER")
+	}
+	scrapeTimezone, err := time.LoadLocation(os.Getenv("SCRAPE_TIMEZONE"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate SCRAPE_TIMEZONE: %w", err)
+	}
+	ecbBaseURL := os.Getenv("ECB_BASE_URL")
+	if ecbBaseURL == "" {
+		ecbBaseURL = defaultECBBaseURL
+	}
 	return &Config{
 		Port:               pkg.MustAtoi(os.Getenv("PORT")),
 		DbConnStr:          os.Getenv("DB_CONN_STR"),
+		MigrateStrict:      migrateStrict,
 		JobKey:             os.Getenv("JOB_KEY"),
 		AppEnv:             os.Getenv("APP_ENV"),
 		YFinanceAPIAuthKey: os.Getenv("YFINANCEAPI_AUTH_KEY"),
 		BuildTime:          buildTime,
+		ECBBaseURL:         ecbBaseURL,
+		ScrapeInterval:     scrapeInterval,
+		ScrapeWindows:      os.Getenv("SCRAPE_WINDOWS"),
+		ScrapeTimezone:     scrapeTimezone,
+
+		ScrapeMaxConsecutiveFailures: scrapeMaxConsecutiveFailures,
+		ScrapeStaleAfter:             scrapeStaleAfter,
+		ScrapeMaxDeviationPercent:    scrapeMaxDeviationPercent,
+		ScrapeAfterClose:             scrapeAfterClose,
+
+		RetentionHourlyAfter: retentionHourlyAfter,
+		RetentionDailyAfter:  retentionDailyAfter,
 	}, nil
 }
+
+// parseRetention parses a retention window, which must keep at least a day of
+// prices at full resolution so today's opening price survives.
+func parseRetention(env string) (time.Duration, error) {
+	value := os.Getenv(env)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to validate %v: %w", env, err)
+	}
+	if d != 0 && d < 24*time.Hour {
+		return 0, fmt.Errorf("failed to validate %v: must be 0 or at least 24h", env)
+	}
+	return d, nil
+}
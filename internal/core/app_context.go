@@ -15,4 +15,8 @@ type AppDeps struct {
 	QuoteService        QuoteService
 	ExchangeRateService ExchangeRateService
 	CurrencyService     CurrencyService
+	SymbolService       SymbolService
+	CalendarService     CalendarService
+	RetentionService    RetentionService
+	ImportService       ImportService
 }
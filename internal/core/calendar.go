@@ -0,0 +1,24 @@
+package core
+
+import "time"
+
+type CalendarService interface {
+	// MarketStatus is the state of an exchange's market at t. Known is false
+	// for symbols without an exchange and for exchanges the bundled calendars
+	// do not cover; such markets count as open.
+	MarketStatus(exchangeID string, t time.Time) MarketStatus
+}
+
+type MarketStatus struct {
+	ExchangeID string `json:"exchangeId"`
+	Known      bool   `json:"known"`
+	Open       bool   `json:"open"`
+	// NextOpen is when the market opens next, if it is closed and the calendar
+	// reaches that far.
+	NextOpen *time.Time `json:"nextOpen,omitempty"`
+}
+
+// Closed reports whether the market is known to be closed.
+func (m MarketStatus) Closed() bool {
+	return m.Known && !m.Open
+}
@@ -2,10 +2,22 @@ package core
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
 
+// ErrNoExchangeRate means no rate is known for the requested pair and date.
+var ErrNoExchangeRate = errors.New("no exchange rate")
+
+// maxRateAge is how far back At looks for the nearest prior business day. It
+// spans the longest TARGET closure (Good Friday to Easter Monday) with room to
+// spare, while refusing to price a date with a rate from months earlier.
+const maxRateAge = 7 * 24 * time.Hour
+
 type CurrencyService interface {
 	ConvertCurrency(ctx context.Context, amount decimal.Decimal, fromCurrency, toCurrency string) (decimal.Decimal, error)
 	ConvertQuoteCurrency(ctx context.Context, quote Quote, toCurrency string) (Quote, error)
@@ -13,4 +25,37 @@ type CurrencyService interface {
 
 type ExchangeRateService interface {
 	GetExchangeRate(ctx context.Context, fromCurrency, toCurrency string) (decimal.Decimal, error)
+	GetExchangeRateSeries(ctx context.Context, fromCurrency, toCurrency string, startDate time.Time, endDate time.Time) (ExchangeRateSeries, error)
+	// LoadHistory stores the full rate history, unless it is already stored.
+	LoadHistory(ctx context.Context) error
+}
+
+// DatedRate is the rate published for a single day.
+type DatedRate struct {
+	Date time.Time
+	Rate decimal.Decimal
+}
+
+// ExchangeRateSeries holds the daily rates of one currency pair, oldest first.
+type ExchangeRateSeries struct {
+	FromCurrency string
+	ToCurrency   string
+	Rates        []DatedRate
+}
+
+// At returns the rate valid at t: the one published on t's UTC date, or else on
+// the nearest prior business day. It returns ErrNoExchangeRate when the series
+// has no rate within maxRateAge before t.
+func (s ExchangeRateSeries) At(t time.Time) (decimal.Decimal, error) {
+	if s.FromCurrency == s.ToCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+	year, month, day := t.UTC().Date()
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	// Index of the first rate after date; the one before it is the candidate.
+	i := sort.Search(len(s.Rates), func(i int) bool { return s.Rates[i].Date.After(date) })
+	if i == 0 || date.Sub(s.Rates[i-1].Date) > maxRateAge {
+		return decimal.Zero, fmt.Errorf("%w for %s to %s on %s", ErrNoExchangeRate, s.FromCurrency, s.ToCurrency, date.Format("2006-01-02"))
+	}
+	return s.Rates[i-1].Rate, nil
 }
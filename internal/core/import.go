@@ -0,0 +1,47 @@
+package core
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+type ImportService interface {
+	// ImportPrices reads CSV prices of a symbol and stores the valid rows in
+	// one transaction, overwriting prices already stored at the same instant.
+	ImportPrices(ctx context.Context, tickerSymbol string, r io.Reader, options ImportOptions) (ImportResult, error)
+}
+
+// ImportOptions maps the columns of a CSV file, found by their header, onto
+// prices. Without a CurrencyColumn every row is in Currency.
+//
+// TimestampFormat is rfc3339, datetime, date, unix or a Go time layout; empty
+// tries rfc3339, datetime and date in turn. Timestamps without an offset are
+// in Location, UTC if nil. Decimal is the decimal separator, "." or ",".
+type ImportOptions struct {
+	Delimiter       rune
+	Decimal         string
+	TimestampColumn string
+	PriceColumn     string
+	CurrencyColumn  string
+	Currency        string
+	TimestampFormat string
+	Location        *time.Location
+}
+
+// ImportResult counts the data rows of an import. Updated rows matched a
+// price already stored, or an earlier row of the same file. Errors lists why
+// rows were rejected, up to a limit.
+type ImportResult struct {
+	Symbol   string           `json:"symbol"`
+	Rows     int              `json:"rows"`
+	Inserted int              `json:"inserted"`
+	Updated  int              `json:"updated"`
+	Rejected int              `json:"rejected"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
@@ -7,10 +7,15 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// The json tags are the field names of /quote/{symbol}?format=json, which
+// clients depend on; the XML keeps the Go field names.
 type Quote struct {
-	Symbol           Symbol
-	Price            Price
-	HistoricalPrices []SimplePrice
+	Symbol           Symbol        `json:"symbol"`
+	Price            Price         `json:"price"`
+	HistoricalPrices []SimplePrice `json:"historicalPrices"`
+	// Bars are only set when the quote is asked for in an interval, and
+	// HistoricalPrices then holds the close of each bar.
+	Bars []Bar `json:"bars,omitempty" xml:",omitempty"`
 }
 
 func (q Quote) ToSerializableQuote() SerializableQuote {
@@ -20,23 +25,58 @@ func (q Quote) ToSerializableQuote() SerializableQuote {
 	}
 }
 
+// ExchangeID is left out of the XML, which spreadsheets address by position.
 type Symbol struct {
-	Symbol string
-	Name   string
+	Symbol     string `json:"symbol"`
+	Name       string `json:"name"`
+	ExchangeID string `json:"exchangeId,omitempty" xml:"-"`
 }
 
+// Source and ScrapedAt are the price's provenance: the scraping source that
+// supplied it and when. Both are empty for prices stored before provenance was
+// recorded, and left out of responses unless asked for.
 type Price struct {
-	Price                decimal.Decimal
-	Currency             string
-	Timestamp            time.Time
-	OpeningPrice         decimal.Decimal
-	PreviousClosingPrice decimal.Decimal
+	Price                decimal.Decimal `json:"price"`
+	Currency             string          `json:"currency"`
+	Timestamp            time.Time       `json:"timestamp"`
+	OpeningPrice         decimal.Decimal `json:"openingPrice"`
+	PreviousClosingPrice decimal.Decimal `json:"previousClosingPrice"`
+	Source               string          `json:"source,omitempty" xml:",omitempty"`
+	ScrapedAt            *time.Time      `json:"scrapedAt,omitempty" xml:",omitempty"`
 }
 
 type SimplePrice struct {
-	Price     decimal.Decimal
-	Currency  string
-	Timestamp time.Time
+	Price     decimal.Decimal `json:"price"`
+	Currency  string          `json:"currency"`
+	Timestamp time.Time       `json:"timestamp"`
+	Source    string          `json:"source,omitempty" xml:",omitempty"`
+	ScrapedAt *time.Time      `json:"scrapedAt,omitempty" xml:",omitempty"`
+}
+
+// Bar is the open, high, low and close of the prices in one currency over an
+// interval starting at Start.
+type Bar struct {
+	Start    time.Time       `json:"start"`
+	Open     decimal.Decimal `json:"open"`
+	High     decimal.Decimal `json:"high"`
+	Low      decimal.Decimal `json:"low"`
+	Close    decimal.Decimal `json:"close"`
+	Currency string          `json:"currency"`
+	Count    int             `json:"count"`
+}
+
+// WithoutProvenance returns a copy of q with every Source and ScrapedAt cleared.
+func (q Quote) WithoutProvenance() Quote {
+	q.Price.Source = ""
+	q.Price.ScrapedAt = nil
+	historicalPrices := make([]SimplePrice, len(q.HistoricalPrices))
+	for i, p := range q.HistoricalPrices {
+		p.Source = ""
+		p.ScrapedAt = nil
+		historicalPrices[i] = p
+	}
+	q.HistoricalPrices = historicalPrices
+	return q
 }
 
 func (p Price) PriceChangeAbsolute() decimal.Decimal {
@@ -67,16 +107,30 @@ func (p Price) ToSerializablePrice() SerializablePrice {
 
 type SerializablePrice struct {
 	Price
-	PriceChangeAbsolute   decimal.Decimal
-	PriceChangePercentage decimal.Decimal
+	PriceChangeAbsolute   decimal.Decimal `json:"priceChangeAbsolute"`
+	PriceChangePercentage decimal.Decimal `json:"priceChangePercentage"`
 }
 
 type SerializableQuote struct {
 	Quote
-	Price SerializablePrice
+	Price SerializablePrice `json:"price"`
 }
 
 type QuoteService interface {
+	// GetQuote gets the quote of a symbol by ticker or by ISIN.
 	GetQuote(ctx context.Context, tickerSymbol string, startDate time.Time, endDate time.Time) (Quote, error)
+	// GetQuotes gets the current price of many symbols at once, without
+	// historical prices. Unknown symbols, and symbols without a price, are left
+	// out; the rest keep the order of tickerSymbols.
+	GetQuotes(ctx context.Context, tickerSymbols []string) ([]Quote, error)
+	// GetQuotesHistory is GetQuotes with each quote's historical prices from
+	// startDate through endDate, fetched for all the symbols at once.
+	GetQuotesHistory(ctx context.Context, tickerSymbols []string, startDate time.Time, endDate time.Time) ([]Quote, error)
+	// GetQuoteBars is GetQuote with the historical prices aggregated into bars
+	// of interval: 1h, 1d or 1w.
+	GetQuoteBars(ctx context.Context, tickerSymbol string, interval string, startDate time.Time, endDate time.Time) (Quote, error)
+	// RebuildBars recomputes the bars of every symbol from its stored prices,
+	// and returns how many symbols had any.
+	RebuildBars(ctx context.Context) (int, error)
 	ClearCache(ctx context.Context, tickerSymbol string) error
 }
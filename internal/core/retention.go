@@ -0,0 +1,34 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrRetentionDisabled means neither retention window is configured.
+var ErrRetentionDisabled = errors.New("price retention is not configured")
+
+type RetentionService interface {
+	// ApplyRetention downsamples old prices according to the configured
+	// retention windows. With dryRun it only reports what it would remove.
+	ApplyRetention(ctx context.Context, dryRun bool) (RetentionReport, error)
+}
+
+// RetentionReport is what a retention run removed, or would have removed in a
+// dry run. Symbols lists only those that lost any prices.
+type RetentionReport struct {
+	DryRun       bool                    `json:"dryRun"`
+	HourlyBefore *time.Time              `json:"hourlyBefore,omitempty"`
+	DailyBefore  *time.Time              `json:"dailyBefore,omitempty"`
+	RowsRemoved  int                     `json:"rowsRemoved"`
+	Symbols      []RetentionSymbolReport `json:"symbols"`
+	StartedAt    time.Time               `json:"startedAt"`
+	FinishedAt   time.Time               `json:"finishedAt"`
+}
+
+type RetentionSymbolReport struct {
+	Symbol        string `json:"symbol"`
+	HourlyRemoved int    `json:"hourlyRemoved"`
+	DailyRemoved  int    `json:"dailyRemoved"`
+}
@@ -1,7 +1,107 @@
 package core
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrScrapeInProgress means a run was skipped because another was still going.
+var ErrScrapeInProgress = errors.New("scrape already in progress")
+
+// ErrMarketClosed means a symbol was not scraped because its market is closed.
+var ErrMarketClosed = errors.New("market closed")
+
+// ErrNotPending means a quarantined price has already been approved or rejected.
+var ErrNotPending = errors.New("quarantined price is not pending")
+
+const (
+	ScrapeTriggerScheduler = "scheduler"
+	ScrapeTriggerAPI       = "api"
+	ScrapeTriggerCLI       = "cli"
+)
 
 type ScraperService interface {
-	ScrapeSymbols(ctx context.Context)
+	// NewRun records a pending run, so its ID can be handed out before
+	// ScrapeSymbols executes it.
+	NewRun(ctx context.Context, trigger string) (int64, error)
+	ScrapeSymbols(ctx context.Context, runID int64) error
+	// ScrapeSymbol scrapes one symbol from its active sources, whether or not
+	// it is due. Unless force is set, a closed market skips the run with
+	// ErrMarketClosed, as a pass would skip the symbol.
+	ScrapeSymbol(ctx context.Context, runID int64, symbolID int64, force bool) error
+	Run(ctx context.Context, runID int64) (ScrapeRun, error)
+	Runs(ctx context.Context, limit int) ([]ScrapeRun, error)
+
+	// QuarantinedPrices lists prices held back by validation. An empty status
+	// lists all of them.
+	QuarantinedPrices(ctx context.Context, status string) ([]QuarantinedPrice, error)
+	// ApproveQuarantinedPrice stores the price after all.
+	ApproveQuarantinedPrice(ctx context.Context, id int64) (QuarantinedPrice, error)
+	RejectQuarantinedPrice(ctx context.Context, id int64) (QuarantinedPrice, error)
+
+	// Backfill fetches the symbol's past prices from a source that serves
+	// them and stores those not already stored.
+	Backfill(ctx context.Context, symbolID int64, input BackfillInput) (BackfillResult, error)
+}
+
+// BackfillInput is a range of past prices to fetch. Start and End are dates,
+// 2006-01-02, or RFC 3339 timestamps; End defaults to now. SourceID defaults to
+// the symbol's highest priority source that serves past prices.
+type BackfillInput struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	SourceID string `json:"sourceId"`
+}
+
+// BackfillResult counts the prices a backfill fetched, how many of those were
+// new, and how many were skipped as invalid.
+type BackfillResult struct {
+	Symbol   string    `json:"symbol"`
+	SourceID string    `json:"sourceId"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Fetched  int       `json:"fetched"`
+	Inserted int       `json:"inserted"`
+	Skipped  int       `json:"skipped"`
+}
+
+type ScrapeRun struct {
+	ID             int64             `json:"id"`
+	Trigger        string            `json:"trigger"`
+	Status         string            `json:"status"`
+	StartedAt      time.Time         `json:"startedAt"`
+	FinishedAt     *time.Time        `json:"finishedAt,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	PricesInserted int               `json:"pricesInserted"`
+	Results        []ScrapeRunResult `json:"results,omitempty"`
+}
+
+// ScrapeRunResult is the outcome of scraping one symbol from one source.
+type ScrapeRunResult struct {
+	Symbol    string           `json:"symbol"`
+	SourceID  string           `json:"sourceId"`
+	Status    string           `json:"status"`
+	Error     string           `json:"error,omitempty"`
+	Price     *decimal.Decimal `json:"price,omitempty"`
+	Currency  string           `json:"currency,omitempty"`
+	Timestamp *time.Time       `json:"timestamp,omitempty"`
+	ScrapedAt time.Time        `json:"scrapedAt"`
+}
+
+// QuarantinedPrice is a scraped price that failed validation badly enough to
+// need a human to approve it before it is stored.
+type QuarantinedPrice struct {
+	ID         int64           `json:"id"`
+	Symbol     string          `json:"symbol"`
+	SourceID   string          `json:"sourceId"`
+	Price      decimal.Decimal `json:"price"`
+	Currency   string          `json:"currency"`
+	Timestamp  time.Time       `json:"timestamp"`
+	ScrapedAt  time.Time       `json:"scrapedAt"`
+	Reason     string          `json:"reason"`
+	Status     string          `json:"status"`
+	ReviewedAt *time.Time      `json:"reviewedAt,omitempty"`
 }
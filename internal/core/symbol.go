@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInvalidInput wraps an error caused by the caller's input, such as a
+// malformed ISIN or an unknown scraping source.
+var ErrInvalidInput = errors.New("invalid input")
+
+// ErrAlreadyExists means a symbol, scraping source or symbol source clashes
+// with an existing one.
+var ErrAlreadyExists = errors.New("already exists")
+
+// SymbolService administers the symbols that are tracked, the sources they
+// are scraped from, and which source is used for which symbol. Lookups of
+// missing records fail with sql.ErrNoRows.
+type SymbolService interface {
+	Symbols(ctx context.Context) ([]SymbolDetails, error)
+	// SearchSymbols finds up to limit symbols by the start of words in their
+	// ticker, name or ISIN, best match first.
+	SearchSymbols(ctx context.Context, query string, limit int) ([]SymbolDetails, error)
+	Symbol(ctx context.Context, id int64) (SymbolDetails, error)
+	CreateSymbol(ctx context.Context, input SymbolInput) (SymbolDetails, error)
+	// UpdateSymbol changes the fields set in input. Setting Active to false
+	// stops the symbol being scraped.
+	UpdateSymbol(ctx context.Context, id int64, input SymbolInput) (SymbolDetails, error)
+	// DeleteSymbol deletes the symbol and all of its prices.
+	DeleteSymbol(ctx context.Context, id int64) error
+
+	Exchanges(ctx context.Context) ([]Exchange, error)
+
+	Sources(ctx context.Context) ([]ScrapingSource, error)
+	Source(ctx context.Context, id string) (ScrapingSource, error)
+	CreateSource(ctx context.Context, input ScrapingSourceInput) (ScrapingSource, error)
+	UpdateSource(ctx context.Context, id string, input ScrapingSourceInput) (ScrapingSource, error)
+	// DeleteSource deletes the source and stops every symbol using it.
+	DeleteSource(ctx context.Context, id string) error
+
+	SymbolSources(ctx context.Context, symbolID int64) ([]SymbolSource, error)
+	CreateSymbolSource(ctx context.Context, symbolID int64, input SymbolSourceInput) (SymbolSource, error)
+	UpdateSymbolSource(ctx context.Context, symbolID int64, id int64, input SymbolSourceInput) (SymbolSource, error)
+	DeleteSymbolSource(ctx context.Context, symbolID int64, id int64) error
+}
+
+// LastScraped is when any of the symbol's sources last scraped it. It is only
+// set in listings.
+type SymbolDetails struct {
+	ID          int64      `json:"id"`
+	Symbol      string     `json:"symbol"`
+	Name        string     `json:"name"`
+	ISIN        string     `json:"isin"`
+	Active      bool       `json:"active"`
+	ExchangeID  string     `json:"exchangeId,omitempty"`
+	LastScraped *time.Time `json:"lastScraped,omitempty"`
+}
+
+// SymbolInput creates or updates a symbol. Nil fields are left unchanged by
+// an update; Symbol and ISIN are required to create one, and Active defaults
+// to true. ExchangeID must be one of Exchanges, or empty for none.
+type SymbolInput struct {
+	Symbol     *string `json:"symbol"`
+	Name       *string `json:"name"`
+	ISIN       *string `json:"isin"`
+	Active     *bool   `json:"active"`
+	ExchangeID *string `json:"exchangeId"`
+}
+
+// Exchange is where a symbol trades. Its time zone decides which prices fall
+// on the same trading day.
+type Exchange struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Timezone string `json:"timezone"`
+}
+
+type ScrapingSource struct {
+	ID                string   `json:"id"`
+	Name              string   `json:"name"`
+	BaseURL           string   `json:"baseUrl"`
+	AdditionalInfo    string   `json:"additionalInfo,omitempty"`
+	MaxConcurrency    int      `json:"maxConcurrency"`
+	RequestsPerSecond *float64 `json:"requestsPerSecond,omitempty"`
+}
+
+// ScrapingSourceInput creates or updates a scraping source. ID is only read on
+// creation, where it must name a scraper this build knows. Nil fields are left
+// unchanged by an update; to create a source, ID, Name and BaseURL are
+// required and MaxConcurrency defaults to 1. A RequestsPerSecond of 0 removes
+// the rate limit.
+type ScrapingSourceInput struct {
+	ID                *string  `json:"id"`
+	Name              *string  `json:"name"`
+	BaseURL           *string  `json:"baseUrl"`
+	AdditionalInfo    *string  `json:"additionalInfo"`
+	MaxConcurrency    *int     `json:"maxConcurrency"`
+	RequestsPerSecond *float64 `json:"requestsPerSecond"`
+}
+
+type SymbolSource struct {
+	ID                  int64      `json:"id"`
+	SymbolID            int64      `json:"symbolId"`
+	SourceID            string     `json:"sourceId"`
+	ScrapeURL           string     `json:"scrapeUrl"`
+	Active              bool       `json:"active"`
+	Priority            int        `json:"priority"`
+	LastScraped         *time.Time `json:"lastScraped,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+}
+
+// SymbolSourceInput creates or updates a symbol source. SourceID is only read
+// on creation, where it and ScrapeURL are required. Nil fields are left
+// unchanged by an update. Reactivating a source clears its failure count.
+type SymbolSourceInput struct {
+	SourceID  *string `json:"sourceId"`
+	ScrapeURL *string `json:"scrapeUrl"`
+	Active    *bool   `json:"active"`
+	Priority  *int    `json:"priority"`
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/bjarke-xyz/stonks/internal/core"
 	"github.com/shopspring/decimal"
@@ -55,14 +56,89 @@ func (c *CurrencyService) ConvertQuoteCurrency(ctx context.Context, quote core.Q
 	quote.Price.Currency = toCurrency
 
 	if len(quote.HistoricalPrices) > 0 {
-		for i, price := range quote.HistoricalPrices {
-			newHistoricalPrice, err := c.ConvertCurrency(ctx, price.Price, price.Currency, toCurrency)
-			if err != nil {
-				return core.Quote{}, err
-			}
-			quote.HistoricalPrices[i].Price = newHistoricalPrice
-			quote.HistoricalPrices[i].Currency = toCurrency
+		historicalPrices, err := c.convertHistoricalPrices(ctx, quote.HistoricalPrices, toCurrency)
+		if err != nil {
+			return core.Quote{}, err
 		}
+		quote.HistoricalPrices = historicalPrices
+	}
+	if len(quote.Bars) > 0 {
+		bars, err := c.convertBars(ctx, quote.Bars, toCurrency)
+		if err != nil {
+			return core.Quote{}, err
+		}
+		quote.Bars = bars
 	}
 	return quote, nil
 }
+
+// convertHistoricalPrices converts each price at the rate valid on its own
+// timestamp, so a long range is not distorted by today's rate. It fails with
+// core.ErrNoExchangeRate rather than fall back to another day's rate.
+func (c *CurrencyService) convertHistoricalPrices(ctx context.Context, prices []core.SimplePrice, toCurrency string) ([]core.SimplePrice, error) {
+	series, err := rateSeries(ctx, c.appContext.Deps.ExchangeRateService, prices, func(p core.SimplePrice) (string, time.Time) { return p.Currency, p.Timestamp }, toCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make([]core.SimplePrice, len(prices))
+	for i, price := range prices {
+		rate, err := series[price.Currency].At(price.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		price.Price = price.Price.Mul(rate)
+		price.Currency = toCurrency
+		converted[i] = price
+	}
+	return converted, nil
+}
+
+// convertBars converts each bar at the rate valid when it starts, like
+// convertHistoricalPrices.
+func (c *CurrencyService) convertBars(ctx context.Context, bars []core.Bar, toCurrency string) ([]core.Bar, error) {
+	series, err := rateSeries(ctx, c.appContext.Deps.ExchangeRateService, bars, func(b core.Bar) (string, time.Time) { return b.Currency, b.Start }, toCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make([]core.Bar, len(bars))
+	for i, bar := range bars {
+		rate, err := series[bar.Currency].At(bar.Start)
+		if err != nil {
+			return nil, err
+		}
+		bar.Open = bar.Open.Mul(rate)
+		bar.High = bar.High.Mul(rate)
+		bar.Low = bar.Low.Mul(rate)
+		bar.Close = bar.Close.Mul(rate)
+		bar.Currency = toCurrency
+		converted[i] = bar
+	}
+	return converted, nil
+}
+
+// rateSeries gets one series per source currency of items, covering every
+// item in that currency.
+func rateSeries[T any](ctx context.Context, exchangeRates core.ExchangeRateService, items []T, currencyAt func(T) (string, time.Time), toCurrency string) (map[string]core.ExchangeRateSeries, error) {
+	first := map[string]time.Time{}
+	last := map[string]time.Time{}
+	for _, item := range items {
+		currency, t := currencyAt(item)
+		if f, ok := first[currency]; !ok || t.Before(f) {
+			first[currency] = t
+		}
+		if l, ok := last[currency]; !ok || t.After(l) {
+			last[currency] = t
+		}
+	}
+	series := map[string]core.ExchangeRateSeries{}
+	for currency := range first {
+		s, err := exchangeRates.GetExchangeRateSeries(ctx, currency, toCurrency, first[currency], last[currency])
+		if err != nil {
+			return nil, fmt.Errorf("error getting exchange rates: %w", err)
+		}
+		series[currency] = s
+	}
+	return series, nil
+}
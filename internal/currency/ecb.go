@@ -0,0 +1,101 @@
+package currency
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+	"github.com/shopspring/decimal"
+)
+
+// The ECB publishes its euro reference rates once per TARGET business day,
+// around 16:00 CET. Every rate is quoted against EUR.
+const (
+	ecbBaseCurrency = "EUR"
+	ecbDailyFeed    = "eurofxref-daily.xml"
+	ecb90DayFeed    = "eurofxref-hist-90d.xml"
+	ecbHistoryFeed  = "eurofxref-hist.xml"
+)
+
+// ecbHistoryStart is the first day in the ECB history.
+var ecbHistoryStart = time.Date(1999, 1, 4, 0, 0, 0, 0, time.UTC)
+
+var ecbClient = &http.Client{Timeout: 30 * time.Second}
+
+// ecbEnvelope is the gesmes envelope around the rates. encoding/xml matches on
+// local names when a tag has no namespace, so the gesmes and eurofxref
+// namespaces can be ignored.
+type ecbEnvelope struct {
+	Cube struct {
+		Days []ecbDay `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+type ecbDay struct {
+	Time  string    `xml:"time,attr"`
+	Rates []ecbRate `xml:"Cube"`
+}
+
+type ecbRate struct {
+	Currency string `xml:"currency,attr"`
+	Rate     string `xml:"rate,attr"`
+}
+
+// fetchECBRates downloads and parses one of the eurofxref feeds below baseURL.
+func fetchECBRates(ctx context.Context, baseURL string, feed string) ([]db.ExchangeRate, error) {
+	url := strings.TrimSuffix(baseURL, "/") + "/" + feed
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := ecbClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error HTTP GETting %v: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error HTTP GETting %v: unexpected status %v", url, resp.Status)
+	}
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	return parseECBRates(bodyBytes)
+}
+
+func parseECBRates(body []byte) ([]db.ExchangeRate, error) {
+	envelope := ecbEnvelope{}
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("error parsing ECB feed: %w", err)
+	}
+
+	var rates []db.ExchangeRate
+	for _, day := range envelope.Cube.Days {
+		date, err := time.Parse("2006-01-02", day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing ECB date %q: %w", day.Time, err)
+		}
+		for _, r := range day.Rates {
+			rate, err := decimal.NewFromString(r.Rate)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing ECB rate %q for %v: %w", r.Rate, r.Currency, err)
+			}
+			rates = append(rates, db.ExchangeRate{
+				Currency: strings.ToUpper(r.Currency),
+				Date:     date,
+				Rate:     rate,
+			})
+		}
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("error parsing ECB feed: no rates found")
+	}
+	return rates, nil
+}
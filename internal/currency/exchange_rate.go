@@ -2,13 +2,30 @@ package currency
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
 	"github.com/shopspring/decimal"
 )
 
+// refreshCacheKey throttles fetching the daily feed. The ECB only publishes
+// once a day, so checking hourly is plenty.
+const (
+	refreshCacheKey          = "EXCHANGE_RATES:REFRESHED"
+	refreshExpirationMinutes = 60
+)
+
+// maxDailyGap is how old the latest stored rate may be before the daily feed
+// alone would leave a hole, and the 90 day feed is fetched instead.
+const maxDailyGap = 5 * 24 * time.Hour
+
 type ExchangeRateService struct {
 	appContext *core.AppContext
 }
@@ -21,18 +38,162 @@ func NewExchangeRateService(appContext *core.AppContext) core.ExchangeRateServic
 
 // GetExchangeRate implements core.ExchangeRateService.
 func (e *ExchangeRateService) GetExchangeRate(ctx context.Context, fromCurrency string, toCurrency string) (decimal.Decimal, error) {
-	// TODO: Get real exchange rates
-	rates := map[string]map[string]decimal.Decimal{
-		"EUR": {
-			"DKK": decimal.NewFromFloat(7.46),
-		},
-		"DKK": {
-			"EUR": decimal.NewFromFloat(0.134),
-		},
+	fromCurrency = strings.ToUpper(fromCurrency)
+	toCurrency = strings.ToUpper(toCurrency)
+	if fromCurrency == toCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+
+	repo, err := db.OpenRepo(e.appContext.Config)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("error opening repo: %w", err)
+	}
+
+	e.refreshRates(ctx, repo)
+
+	fromRate, err := e.euroRate(ctx, repo, fromCurrency)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	toRate, err := e.euroRate(ctx, repo, toCurrency)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	// Both rates are per EUR, so from -> EUR -> to is toRate / fromRate.
+	return toRate.Div(fromRate), nil
+}
+
+// GetExchangeRateSeries implements core.ExchangeRateService.
+func (e *ExchangeRateService) GetExchangeRateSeries(ctx context.Context, fromCurrency string, toCurrency string, startDate time.Time, endDate time.Time) (core.ExchangeRateSeries, error) {
+	fromCurrency = strings.ToUpper(fromCurrency)
+	toCurrency = strings.ToUpper(toCurrency)
+	series := core.ExchangeRateSeries{FromCurrency: fromCurrency, ToCurrency: toCurrency}
+	if fromCurrency == toCurrency {
+		return series, nil
 	}
 
-	if rate, ok := rates[strings.ToUpper(fromCurrency)][strings.ToUpper(toCurrency)]; ok {
-		return rate, nil
+	repo, err := db.OpenRepo(e.appContext.Config)
+	if err != nil {
+		return core.ExchangeRateSeries{}, fmt.Errorf("error opening repo: %w", err)
+	}
+
+	e.refreshRates(ctx, repo)
+
+	fromSeries, err := e.euroSeries(ctx, repo, fromCurrency, startDate, endDate)
+	if err != nil {
+		return core.ExchangeRateSeries{}, err
+	}
+	toSeries, err := e.euroSeries(ctx, repo, toCurrency, startDate, endDate)
+	if err != nil {
+		return core.ExchangeRateSeries{}, err
+	}
+
+	// The ECB publishes every currency on the same days, but a currency can
+	// join or leave the feed, so walk the union of both sides' dates.
+	dates := map[time.Time]bool{}
+	for _, r := range fromSeries.Rates {
+		dates[r.Date] = true
+	}
+	for _, r := range toSeries.Rates {
+		dates[r.Date] = true
+	}
+	for date := range dates {
+		fromRate, err := fromSeries.At(date)
+		if err != nil {
+			continue
+		}
+		toRate, err := toSeries.At(date)
+		if err != nil {
+			continue
+		}
+		series.Rates = append(series.Rates, core.DatedRate{Date: date, Rate: toRate.Div(fromRate)})
+	}
+	sort.Slice(series.Rates, func(i, j int) bool { return series.Rates[i].Date.Before(series.Rates[j].Date) })
+	return series, nil
+}
+
+// euroRate is how many units of currency one EUR buys.
+func (e *ExchangeRateService) euroRate(ctx context.Context, repo *db.Repo, currency string) (decimal.Decimal, error) {
+	if currency == ecbBaseCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+	rate, err := repo.LatestExchangeRate(ctx, currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return decimal.Zero, fmt.Errorf("exchange rate not found for %s", currency)
+	}
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("error getting exchange rate for %s: %w", currency, err)
+	}
+	return rate.Rate, nil
+}
+
+// euroSeries is the EUR -> currency series. For EUR itself it is the identity.
+func (e *ExchangeRateService) euroSeries(ctx context.Context, repo *db.Repo, currency string, startDate time.Time, endDate time.Time) (core.ExchangeRateSeries, error) {
+	series := core.ExchangeRateSeries{FromCurrency: ecbBaseCurrency, ToCurrency: currency}
+	if currency == ecbBaseCurrency {
+		return series, nil
+	}
+	rates, err := repo.ExchangeRates(ctx, currency, startDate, endDate)
+	if err != nil {
+		return core.ExchangeRateSeries{}, fmt.Errorf("error getting exchange rates for %s: %w", currency, err)
+	}
+	if len(rates) == 0 {
+		return core.ExchangeRateSeries{}, fmt.Errorf("%w for %s", core.ErrNoExchangeRate, currency)
+	}
+	for _, rate := range rates {
+		series.Rates = append(series.Rates, core.DatedRate{Date: rate.Date, Rate: rate.Rate})
+	}
+	return series, nil
+}
+
+// refreshRates stores the latest rates, at most once per
+// refreshExpirationMinutes. The daily feed only carries today, so after a
+// longer gap the 90 day feed is fetched instead. A failed fetch is only logged:
+// the stored rates are at worst a few days old, which beats failing the request.
+func (e *ExchangeRateService) refreshRates(ctx context.Context, repo *db.Repo) {
+	refreshed, _ := e.appContext.Deps.Cache.Get(refreshCacheKey)
+	if refreshed != "" {
+		return
+	}
+	feed := ecbDailyFeed
+	_, latest, err := repo.ExchangeRateDates(ctx)
+	if err != nil || time.Since(latest) > maxDailyGap {
+		feed = ecb90DayFeed
+	}
+	if err := e.fetchAndStore(ctx, repo, feed); err != nil {
+		slog.Warn("refreshing ECB exchange rates failed", "feed", feed, "error", err)
+		return
+	}
+	e.appContext.Deps.Cache.Insert(refreshCacheKey, "1", refreshExpirationMinutes)
+}
+
+// LoadHistory implements core.ExchangeRateService. The full history is tens of
+// megabytes, far too much to fetch while serving a request, so the scheduler
+// calls this once at startup. Until it has run, series only reach back as far
+// as the 90 day feed.
+func (e *ExchangeRateService) LoadHistory(ctx context.Context) error {
+	repo, err := db.OpenRepo(e.appContext.Config)
+	if err != nil {
+		return fmt.Errorf("error opening repo: %w", err)
+	}
+	earliest, _, err := repo.ExchangeRateDates(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting exchange rate dates: %w", err)
+	}
+	if !earliest.IsZero() && !earliest.After(ecbHistoryStart) {
+		return nil
+	}
+	return e.fetchAndStore(ctx, repo, ecbHistoryFeed)
+}
+
+func (e *ExchangeRateService) fetchAndStore(ctx context.Context, repo *db.Repo, feed string) error {
+	rates, err := fetchECBRates(ctx, e.appContext.Config.ECBBaseURL, feed)
+	if err != nil {
+		return err
+	}
+	if err := repo.InsertExchangeRates(ctx, rates); err != nil {
+		return fmt.Errorf("error storing exchange rates: %w", err)
 	}
-	return decimal.Zero, fmt.Errorf("exchange rate not found for %s to %s", fromCurrency, toCurrency)
+	slog.Debug("stored ECB exchange rates", "feed", feed, "count", len(rates))
+	return nil
 }
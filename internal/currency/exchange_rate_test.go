@@ -0,0 +1,222 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/config"
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/repository"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+	"github.com/shopspring/decimal"
+)
+
+// Trimmed copy of eurofxref-daily.xml, namespaces and all.
+const ecbDailyFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender>
+		<gesmes:name>European Central Bank</gesmes:name>
+	</gesmes:Sender>
+	<Cube>
+		<Cube time='2026-07-08'>
+			<Cube currency='USD' rate='1.1000'/>
+			<Cube currency='DKK' rate='7.4600'/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+// 2026-07-04 and 2026-07-05 are a weekend, so carry no rates.
+const ecbHistoryFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time='2026-07-08'>
+			<Cube currency='USD' rate='1.1000'/>
+			<Cube currency='DKK' rate='7.4600'/>
+		</Cube>
+		<Cube time='2026-07-06'>
+			<Cube currency='USD' rate='1.2000'/>
+			<Cube currency='DKK' rate='7.4500'/>
+		</Cube>
+		<Cube time='2026-07-03'>
+			<Cube currency='USD' rate='1.0000'/>
+			<Cube currency='DKK' rate='7.4400'/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+// The 90 day fixture plus the first day of the ECB history.
+const ecbFullHistoryFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time='2026-07-08'>
+			<Cube currency='USD' rate='1.1000'/>
+		</Cube>
+		<Cube time='2026-07-06'>
+			<Cube currency='USD' rate='1.2000'/>
+		</Cube>
+		<Cube time='2026-07-03'>
+			<Cube currency='USD' rate='1.0000'/>
+		</Cube>
+		<Cube time='1999-01-04'>
+			<Cube currency='USD' rate='1.1789'/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func newTestExchangeRateService(t *testing.T) *ExchangeRateService {
+	t.Helper()
+	feeds := map[string]string{
+		"/" + ecbDailyFeed:   ecbDailyFixture,
+		"/" + ecb90DayFeed:   ecbHistoryFixture,
+		"/" + ecbHistoryFeed: ecbFullHistoryFixture,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		feed, ok := feeds[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(feed))
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{
+		DbConnStr:  filepath.Join(t.TempDir(), "stonks.db"),
+		ECBBaseURL: srv.URL,
+	}
+	conn, err := db.Open(cfg)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.Migrate("up", conn, 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	appContext := &core.AppContext{
+		Config: cfg,
+		Deps:   &core.AppDeps{Cache: repository.NewCacheService(repository.NewCacheRepo(cfg, true))},
+	}
+	return &ExchangeRateService{appContext: appContext}
+}
+
+func TestGetExchangeRateTriangulatesThroughEUR(t *testing.T) {
+	e := newTestExchangeRateService(t)
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{"EUR", "USD", "1.1"},
+		{"usd", "eur", "0.9090909090909091"},
+		{"USD", "DKK", "6.7818181818181818"},
+		{"DKK", "DKK", "1"},
+	}
+	for _, tt := range tests {
+		got, err := e.GetExchangeRate(context.Background(), tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("%s->%s: %v", tt.from, tt.to, err)
+		}
+		if !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("%s->%s = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestGetExchangeRateUnknownCurrency(t *testing.T) {
+	e := newTestExchangeRateService(t)
+	if _, err := e.GetExchangeRate(context.Background(), "EUR", "XYZ"); err == nil {
+		t.Error("EUR->XYZ succeeded, want an error")
+	}
+}
+
+func TestConvertQuoteCurrencyUsesRateOfEachDay(t *testing.T) {
+	e := newTestExchangeRateService(t)
+	e.appContext.Deps.ExchangeRateService = e
+	c := &CurrencyService{appContext: e.appContext}
+
+	day := func(d int) time.Time { return time.Date(2026, 7, d, 12, 0, 0, 0, time.UTC) }
+	quote := core.Quote{
+		Price: core.Price{Price: decimal.NewFromInt(10), Currency: "EUR", Timestamp: day(8)},
+		HistoricalPrices: []core.SimplePrice{
+			{Price: decimal.NewFromInt(10), Currency: "EUR", Timestamp: day(3)},
+			{Price: decimal.NewFromInt(10), Currency: "EUR", Timestamp: day(5)}, // Sunday: Friday's rate
+			{Price: decimal.NewFromInt(10), Currency: "EUR", Timestamp: day(6)},
+			{Price: decimal.NewFromInt(10), Currency: "EUR", Timestamp: day(7)}, // missing from the feed: the 6th's
+		},
+	}
+
+	converted, err := c.ConvertQuoteCurrency(context.Background(), quote, "USD")
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	for i, want := range []string{"10", "10", "12", "12"} {
+		if got := converted.HistoricalPrices[i].Price; !got.Equal(decimal.RequireFromString(want)) {
+			t.Errorf("price %d = %v, want %v", i, got, want)
+		}
+	}
+	if got := converted.Price.Price; !got.Equal(decimal.NewFromInt(11)) {
+		t.Errorf("latest price = %v, want 11", got)
+	}
+
+	// Before the first rate there is nothing to fall back on.
+	quote.HistoricalPrices = []core.SimplePrice{{Price: decimal.NewFromInt(10), Currency: "EUR", Timestamp: day(1)}}
+	if _, err := c.ConvertQuoteCurrency(context.Background(), quote, "USD"); !errors.Is(err, core.ErrNoExchangeRate) {
+		t.Errorf("converting a price before any rate: err = %v, want ErrNoExchangeRate", err)
+	}
+}
+
+func TestLoadHistory(t *testing.T) {
+	ctx := context.Background()
+	e := newTestExchangeRateService(t)
+	// A series request only fetches the recent rates.
+	if _, err := e.GetExchangeRateSeries(ctx, "EUR", "USD", time.Date(1999, 1, 4, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 8, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := db.OpenRepo(e.appContext.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	earliest, _, err := repo.ExchangeRateDates(ctx)
+	if err != nil || !earliest.Equal(time.Date(2026, 7, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("earliest rate before loading the history = %v (%v), want 2026-07-03", earliest, err)
+	}
+
+	if err := e.LoadHistory(ctx); err != nil {
+		t.Fatal(err)
+	}
+	earliest, _, err = repo.ExchangeRateDates(ctx)
+	if err != nil || !earliest.Equal(ecbHistoryStart) {
+		t.Fatalf("earliest rate = %v (%v), want %v", earliest, err, ecbHistoryStart)
+	}
+
+	// Once stored, the history is not fetched again.
+	e.appContext.Config.ECBBaseURL = "http://127.0.0.1:0"
+	if err := e.LoadHistory(ctx); err != nil {
+		t.Errorf("loading the stored history again: %v", err)
+	}
+}
+
+// A range given in another time zone covers the same UTC dates as the rates.
+func TestGetExchangeRateSeriesLocalRange(t *testing.T) {
+	e := newTestExchangeRateService(t)
+	cest := time.FixedZone("CEST", 2*60*60)
+	// Monday 00:30 CEST is still Sunday in UTC, which falls back to Friday.
+	start := time.Date(2026, 7, 6, 0, 30, 0, 0, cest)
+	series, err := e.GetExchangeRateSeries(context.Background(), "EUR", "USD", start, time.Date(2026, 7, 6, 23, 0, 0, 0, cest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rate, err := series.At(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rate.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("rate at %v = %v, want Friday's 1", start, rate)
+	}
+}
@@ -0,0 +1,301 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+	"github.com/bjarke-xyz/stonks/pkg"
+	"github.com/shopspring/decimal"
+)
+
+// maxReportedErrors bounds ImportResult.Errors, so a file in the wrong format
+// does not answer with one error per line.
+const maxReportedErrors = 100
+
+// maxFutureSkew allows for timestamps a little ahead, as when scraping.
+const maxFutureSkew = time.Hour
+
+type ImportService struct {
+	appContext *core.AppContext
+}
+
+func NewImportService(appContext *core.AppContext) core.ImportService {
+	return &ImportService{appContext: appContext}
+}
+
+// ImportPrices implements core.ImportService. tickerSymbol may also be an
+// ISIN. Imported prices have no source, like prices stored before provenance
+// was recorded. A malformed header or CSV fails the whole import; an invalid
+// row is only rejected.
+func (s *ImportService) ImportPrices(ctx context.Context, tickerSymbol string, r io.Reader, options core.ImportOptions) (core.ImportResult, error) {
+	options = withDefaults(options)
+	if options.Decimal != "." && options.Decimal != "," {
+		return core.ImportResult{}, fmt.Errorf("%w: invalid decimal separator %q, want \".\" or \",\"", core.ErrInvalidInput, options.Decimal)
+	}
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return core.ImportResult{}, fmt.Errorf("error opening db: %w", err)
+	}
+	symbol, err := symbolByTickerOrISIN(ctx, repo, strings.ToUpper(tickerSymbol))
+	if err != nil {
+		return core.ImportResult{}, fmt.Errorf("error getting symbol %v: %w", tickerSymbol, err)
+	}
+
+	result := core.ImportResult{Symbol: symbol.Symbol, Errors: []core.ImportRowError{}}
+	reject := func(line int, err error) {
+		result.Rejected++
+		if len(result.Errors) < maxReportedErrors {
+			result.Errors = append(result.Errors, core.ImportRowError{Line: line, Error: err.Error()})
+		}
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = options.Delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return result, fmt.Errorf("%w: error reading header: %w", core.ErrInvalidInput, err)
+	}
+	columns, err := mapColumns(header, options)
+	if err != nil {
+		return result, err
+	}
+
+	var prices []db.Price
+	var first, last time.Time
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return result, fmt.Errorf("%w: %w", core.ErrInvalidInput, err)
+		}
+		if err != nil {
+			return result, fmt.Errorf("error reading csv: %w", err)
+		}
+		result.Rows++
+		line, _ := reader.FieldPos(0)
+		price, err := parseRow(record, columns, options)
+		if err != nil {
+			reject(line, err)
+			continue
+		}
+		price.SymbolID = symbol.ID
+		prices = append(prices, price)
+		if first.IsZero() || price.Timestamp.Before(first) {
+			first = price.Timestamp
+		}
+		if price.Timestamp.After(last) {
+			last = price.Timestamp
+		}
+	}
+	if len(prices) == 0 {
+		return result, nil
+	}
+
+	err = repo.InTx(ctx, func(tx *db.Repo) error {
+		for _, price := range prices {
+			inserted, err := tx.UpsertPrice(ctx, price)
+			if err != nil {
+				return fmt.Errorf("error inserting price at %v: %w", price.Timestamp.Format(time.RFC3339), err)
+			}
+			if inserted {
+				result.Inserted++
+			} else {
+				result.Updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return core.ImportResult{}, err
+	}
+
+	if err := repo.RefreshPriceBars(ctx, symbol.ID, first, last); err != nil {
+		slog.Warn("refreshing price bars after import failed", "symbol", symbol.Symbol, "error", err)
+	}
+	s.appContext.Deps.QuoteService.ClearCache(ctx, symbol.Symbol)
+	slog.Info("imported prices", "symbol", symbol.Symbol, "rows", result.Rows, "inserted", result.Inserted, "updated", result.Updated, "rejected", result.Rejected)
+	return result, nil
+}
+
+// ParseDelimiter parses a delimiter as given in a query or flag: a single
+// character, "tab" or "semicolon", since a bare ";" is not a valid query
+// separator. An empty value is a comma.
+func ParseDelimiter(delimiter string) (rune, error) {
+	switch {
+	case delimiter == "":
+		return ',', nil
+	case delimiter == "tab":
+		return '\t', nil
+	case delimiter == "semicolon":
+		return ';', nil
+	case utf8.RuneCountInString(delimiter) == 1:
+		r, _ := utf8.DecodeRuneInString(delimiter)
+		if r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+			return 0, fmt.Errorf("%w: invalid delimiter %q", core.ErrInvalidInput, delimiter)
+		}
+		return r, nil
+	default:
+		return 0, fmt.Errorf("%w: invalid delimiter %q, want a single character, \"tab\" or \"semicolon\"", core.ErrInvalidInput, delimiter)
+	}
+}
+
+func withDefaults(options core.ImportOptions) core.ImportOptions {
+	if options.Delimiter == 0 {
+		options.Delimiter = ','
+	}
+	if options.Decimal == "" {
+		options.Decimal = "."
+	}
+	if options.TimestampColumn == "" {
+		options.TimestampColumn = "timestamp"
+	}
+	if options.PriceColumn == "" {
+		options.PriceColumn = "price"
+	}
+	if options.CurrencyColumn == "" && options.Currency == "" {
+		options.CurrencyColumn = "currency"
+	}
+	if options.Location == nil {
+		options.Location = time.UTC
+	}
+	return options
+}
+
+func symbolByTickerOrISIN(ctx context.Context, repo *db.Repo, tickerSymbol string) (db.Symbol, error) {
+	symbol, err := repo.SymbolByTicker(ctx, tickerSymbol)
+	if errors.Is(err, sql.ErrNoRows) && pkg.IsISIN(tickerSymbol) {
+		return repo.SymbolByISIN(ctx, tickerSymbol)
+	}
+	return symbol, err
+}
+
+// columns are the indexes of the mapped columns; currency is -1 when every
+// row is in options.Currency.
+type columns struct {
+	timestamp, price, currency int
+}
+
+func mapColumns(header []string, options core.ImportOptions) (columns, error) {
+	find := func(name string) (int, error) {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")), name) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("%w: no column %q in header %q", core.ErrInvalidInput, name, strings.Join(header, string(options.Delimiter)))
+	}
+	var c columns
+	var err error
+	if c.timestamp, err = find(options.TimestampColumn); err != nil {
+		return c, err
+	}
+	if c.price, err = find(options.PriceColumn); err != nil {
+		return c, err
+	}
+	c.currency = -1
+	if options.CurrencyColumn != "" {
+		if c.currency, err = find(options.CurrencyColumn); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+func parseRow(record []string, c columns, options core.ImportOptions) (db.Price, error) {
+	field := func(i int) string {
+		if i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	timestamp, err := parseTimestamp(field(c.timestamp), options)
+	if err != nil {
+		return db.Price{}, err
+	}
+	if timestamp.After(time.Now().Add(maxFutureSkew)) {
+		return db.Price{}, fmt.Errorf("timestamp %v is in the future", timestamp.Format(time.RFC3339))
+	}
+
+	priceStr := field(c.price)
+	if options.Decimal == "," {
+		priceStr = strings.Replace(priceStr, ",", ".", 1)
+	}
+	price, err := decimal.NewFromString(priceStr)
+	if err != nil {
+		return db.Price{}, fmt.Errorf("invalid price %q", field(c.price))
+	}
+	if !price.IsPositive() {
+		return db.Price{}, fmt.Errorf("price %v is not positive", price)
+	}
+
+	currency := options.Currency
+	if c.currency >= 0 {
+		currency = field(c.currency)
+	}
+	currency = strings.ToUpper(currency)
+	if !isCurrencyCode(currency) {
+		return db.Price{}, fmt.Errorf("invalid currency %q", currency)
+	}
+
+	return db.Price{Price: price, Currency: currency, Timestamp: timestamp.UTC()}, nil
+}
+
+func parseTimestamp(value string, options core.ImportOptions) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("no timestamp")
+	}
+	var layouts []string
+	switch options.TimestampFormat {
+	case "":
+		layouts = []string{time.RFC3339, time.DateTime, time.DateOnly}
+	case "rfc3339":
+		layouts = []string{time.RFC3339}
+	case "datetime":
+		layouts = []string{time.DateTime}
+	case "date":
+		layouts = []string{time.DateOnly}
+	case "unix":
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid unix timestamp %q", value)
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	default:
+		layouts = []string{options.TimestampFormat}
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, options.Location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+}
+
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+)
+
+func TestParseRow(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := []string{"\ufeffDate", "Close", "Ccy"}
+	tests := []struct {
+		name      string
+		record    []string
+		options   core.ImportOptions
+		price     string
+		currency  string
+		timestamp string
+		err       bool
+	}{
+		{"date in time zone", []string{"2025-01-02", "10.5", "eur"}, core.ImportOptions{Location: berlin}, "10.5", "EUR", "2025-01-01T23:00:00Z", false},
+		{"offset wins over time zone", []string{"2025-01-02T10:00:00+01:00", "10.5", "EUR"}, core.ImportOptions{Location: berlin}, "10.5", "EUR", "2025-01-02T09:00:00Z", false},
+		{"decimal comma", []string{"2025-01-02 10:00:00", "1,25", "DKK"}, core.ImportOptions{Decimal: ","}, "1.25", "DKK", "2025-01-02T10:00:00Z", false},
+		{"unix", []string{"1735812000", "3", "USD"}, core.ImportOptions{TimestampFormat: "unix"}, "3", "USD", "2025-01-02T10:00:00Z", false},
+		{"custom layout", []string{"02/01/2025", "3", "USD"}, core.ImportOptions{TimestampFormat: "02/01/2006"}, "3", "USD", "2025-01-02T00:00:00Z", false},
+		{"fixed currency", []string{"2025-01-02", "3", ""}, core.ImportOptions{Currency: "sek"}, "3", "SEK", "2025-01-02T00:00:00Z", false},
+		{"negative price", []string{"2025-01-02", "-1", "EUR"}, core.ImportOptions{}, "", "", "", true},
+		{"invalid currency", []string{"2025-01-02", "1", "euro"}, core.ImportOptions{}, "", "", "", true},
+		{"missing field", []string{"2025-01-02"}, core.ImportOptions{}, "", "", "", true},
+		{"future", []string{"2999-01-02", "1", "EUR"}, core.ImportOptions{}, "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tt.options
+			options.TimestampColumn, options.PriceColumn = "date", "close"
+			if options.Currency == "" {
+				options.CurrencyColumn = "ccy"
+			}
+			options = withDefaults(options)
+			columns, err := mapColumns(header, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			price, err := parseRow(tt.record, columns, options)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", price)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if price.Price.String() != tt.price || price.Currency != tt.currency || price.Timestamp.Format(time.RFC3339) != tt.timestamp {
+				t.Fatalf("got %v %v at %v, want %v %v at %v", price.Price, price.Currency, price.Timestamp.Format(time.RFC3339), tt.price, tt.currency, tt.timestamp)
+			}
+		})
+	}
+}
@@ -2,13 +2,18 @@ package quote
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/bjarke-xyz/stonks/internal/core"
 	"github.com/bjarke-xyz/stonks/internal/repository/db"
+	"github.com/bjarke-xyz/stonks/pkg"
+	"github.com/samber/lo"
 )
 
 type QuoteService struct {
@@ -22,9 +27,37 @@ func NewQuoteService(appContext *core.AppContext) core.QuoteService {
 func (q *QuoteService) ClearCache(ctx context.Context, tickerSymbol string) error {
 	return q.appContext.Deps.Cache.DeleteByPrefix("QUOTE:" + tickerSymbol)
 }
+
+// GetQuote implements core.QuoteService. tickerSymbol may also be an ISIN.
 func (q *QuoteService) GetQuote(ctx context.Context, tickerSymbol string, startDate time.Time, endDate time.Time) (core.Quote, error) {
+	return q.getQuote(ctx, tickerSymbol, "", startDate, endDate)
+}
+
+// GetQuoteBars implements core.QuoteService.
+func (q *QuoteService) GetQuoteBars(ctx context.Context, tickerSymbol string, interval string, startDate time.Time, endDate time.Time) (core.Quote, error) {
+	if !slices.Contains(db.PriceBarIntervals, interval) {
+		return core.Quote{}, fmt.Errorf("%w: interval must be one of %v", core.ErrInvalidInput, strings.Join(db.PriceBarIntervals, ", "))
+	}
+	return q.getQuote(ctx, tickerSymbol, interval, startDate, endDate)
+}
+
+// getQuote gets the quote with raw historical prices, or with bars of
+// interval if it is set.
+func (q *QuoteService) getQuote(ctx context.Context, tickerSymbol string, interval string, startDate time.Time, endDate time.Time) (core.Quote, error) {
 	tickerSymbol = strings.ToUpper(tickerSymbol)
+	if pkg.IsISIN(tickerSymbol) {
+		// Resolved up front so the quote is cached under its ticker, which is
+		// what ClearCache is called with.
+		ticker, err := q.resolveISIN(ctx, tickerSymbol)
+		if err != nil {
+			return core.Quote{}, err
+		}
+		tickerSymbol = ticker
+	}
 	cacheKey := fmt.Sprintf("QUOTE:%v:%v:%v", tickerSymbol, startDate.Unix(), endDate.Unix())
+	if interval != "" {
+		cacheKey += ":" + interval
+	}
 	quote := core.Quote{}
 	inCache, _ := q.appContext.Deps.Cache.GetObj(cacheKey, &quote)
 	if inCache {
@@ -45,24 +78,133 @@ func (q *QuoteService) GetQuote(ctx context.Context, tickerSymbol string, startD
 	if err != nil {
 		return core.Quote{}, fmt.Errorf("error getting price for symbol %v: %w", symbol.Symbol, err)
 	}
+	quote = toCoreQuote(symbol, priceQuote)
 
-	dbHistoricalPrices, err := repo.HistoricalPrices(ctx, symbol.ID, startDate, endDate)
+	if interval == "" {
+		dbHistoricalPrices, err := repo.HistoricalPrices(ctx, symbol.ID, startDate, endDate)
+		if err != nil {
+			return core.Quote{}, fmt.Errorf("error getting historical prices for symbol %v: %w", symbol.Symbol, err)
+		}
+		quote.HistoricalPrices = toCoreHistoricalPrices(dbHistoricalPrices)
+	} else {
+		dbBars, err := repo.PriceBars(ctx, symbol.ID, interval, startDate, endDate)
+		if err != nil {
+			return core.Quote{}, fmt.Errorf("error getting %v bars for symbol %v: %w", interval, symbol.Symbol, err)
+		}
+		quote.Bars = toCoreBars(dbBars)
+		quote.HistoricalPrices = lo.Map(quote.Bars, func(b core.Bar, _ int) core.SimplePrice {
+			return core.SimplePrice{Price: b.Close, Currency: b.Currency, Timestamp: b.Start}
+		})
+	}
+	q.appContext.Deps.Cache.InsertObj(cacheKey, quote, 30)
+	return quote, nil
+}
+
+// RebuildBars implements core.QuoteService.
+func (q *QuoteService) RebuildBars(ctx context.Context) (int, error) {
+	repo, err := db.OpenRepo(q.appContext.Config)
 	if err != nil {
-		return core.Quote{}, fmt.Errorf("error getting historical prices for symbol %v: %w", symbol.Symbol, err)
+		return 0, fmt.Errorf("error opening repo: %w", err)
 	}
-	historicalPrices := make([]core.SimplePrice, len(dbHistoricalPrices))
-	for i, histPrice := range dbHistoricalPrices {
-		historicalPrices[i] = core.SimplePrice{
-			Price:     histPrice.Price,
-			Currency:  histPrice.Currency,
-			Timestamp: histPrice.Timestamp,
+	symbols, err := repo.Symbols(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error getting symbols: %w", err)
+	}
+	rebuilt := 0
+	for _, symbol := range symbols {
+		hasPrices, err := repo.RebuildPriceBars(ctx, symbol.ID)
+		if err != nil {
+			return rebuilt, fmt.Errorf("error rebuilding bars of symbol %v: %w", symbol.Symbol.Symbol, err)
+		}
+		if hasPrices {
+			rebuilt++
 		}
+		q.ClearCache(ctx, symbol.Symbol.Symbol)
 	}
+	return rebuilt, nil
+}
 
-	quote = core.Quote{
+// resolveISIN returns the ticker of the symbol with the ISIN. Failing that it
+// returns isin itself, in case it is a ticker that happens to look like one.
+func (q *QuoteService) resolveISIN(ctx context.Context, isin string) (string, error) {
+	repo, err := db.OpenRepo(q.appContext.Config)
+	if err != nil {
+		return "", fmt.Errorf("error opening repo: %w", err)
+	}
+	symbol, err := repo.SymbolByISIN(ctx, isin)
+	if errors.Is(err, sql.ErrNoRows) {
+		return isin, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error getting symbol by isin %v: %w", isin, err)
+	}
+	return symbol.Symbol, nil
+}
+
+// GetQuotes implements core.QuoteService. It is not cached: it costs two
+// queries however many symbols are asked for.
+func (q *QuoteService) GetQuotes(ctx context.Context, tickerSymbols []string) ([]core.Quote, error) {
+	return q.getQuotes(ctx, tickerSymbols, false, time.Time{}, time.Time{})
+}
+
+// GetQuotesHistory implements core.QuoteService. Like GetQuotes it is not
+// cached, and costs three queries however many symbols are asked for.
+func (q *QuoteService) GetQuotesHistory(ctx context.Context, tickerSymbols []string, startDate time.Time, endDate time.Time) ([]core.Quote, error) {
+	return q.getQuotes(ctx, tickerSymbols, true, startDate, endDate)
+}
+
+// getQuotes gets the quotes of tickerSymbols, with their historical prices
+// from startDate through endDate if withHistory is set.
+func (q *QuoteService) getQuotes(ctx context.Context, tickerSymbols []string, withHistory bool, startDate time.Time, endDate time.Time) ([]core.Quote, error) {
+	tickerSymbols = lo.Uniq(lo.Map(tickerSymbols, func(t string, _ int) string { return strings.ToUpper(t) }))
+	repo, err := db.OpenRepo(q.appContext.Config)
+	if err != nil {
+		return nil, fmt.Errorf("error opening repo: %w", err)
+	}
+
+	symbols, err := repo.SymbolsByTickers(ctx, tickerSymbols)
+	if err != nil {
+		return nil, fmt.Errorf("error getting symbols: %w", err)
+	}
+	symbolsByTicker := lo.KeyBy(symbols, func(s db.Symbol) string { return s.Symbol })
+	symbolIDs := lo.Map(symbols, func(s db.Symbol, _ int) int64 { return s.ID })
+
+	priceQuotes, err := repo.Quotes(ctx, symbolIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting prices: %w", err)
+	}
+	var historicalPrices map[int64][]db.HistoricalPrice
+	if withHistory {
+		if historicalPrices, err = repo.HistoricalPricesBySymbols(ctx, symbolIDs, startDate, endDate); err != nil {
+			return nil, fmt.Errorf("error getting historical prices: %w", err)
+		}
+	}
+
+	var quotes []core.Quote
+	for _, ticker := range tickerSymbols {
+		symbol, ok := symbolsByTicker[ticker]
+		if !ok {
+			continue
+		}
+		priceQuote, ok := priceQuotes[symbol.ID]
+		if !ok {
+			continue
+		}
+		quote := toCoreQuote(symbol, priceQuote)
+		if withHistory {
+			quote.HistoricalPrices = toCoreHistoricalPrices(historicalPrices[symbol.ID])
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, nil
+}
+
+func toCoreQuote(symbol db.Symbol, priceQuote db.PriceQuote) core.Quote {
+	return core.Quote{
 		Symbol: core.Symbol{
-			Symbol: symbol.Symbol,
-			Name:   symbol.Name.String,
+			Symbol:     symbol.Symbol,
+			Name:       symbol.Name.String,
+			ExchangeID: symbol.ExchangeID.String,
 		},
 		Price: core.Price{
 			Price:                priceQuote.LatestPrice,
@@ -70,9 +212,43 @@ func (q *QuoteService) GetQuote(ctx context.Context, tickerSymbol string, startD
 			Timestamp:            priceQuote.Timestamp,
 			OpeningPrice:         priceQuote.OpeningPrice,
 			PreviousClosingPrice: priceQuote.PreviousClosingPrice,
+			Source:               priceQuote.SourceID.String,
+			ScrapedAt:            nullTimePtr(priceQuote.ScrapedAt),
 		},
-		HistoricalPrices: historicalPrices,
 	}
-	q.appContext.Deps.Cache.InsertObj(cacheKey, quote, 30)
-	return quote, nil
+}
+
+func toCoreHistoricalPrices(dbHistoricalPrices []db.HistoricalPrice) []core.SimplePrice {
+	historicalPrices := make([]core.SimplePrice, len(dbHistoricalPrices))
+	for i, histPrice := range dbHistoricalPrices {
+		historicalPrices[i] = core.SimplePrice{
+			Price:     histPrice.Price,
+			Currency:  histPrice.Currency,
+			Timestamp: histPrice.Timestamp,
+			Source:    histPrice.SourceID.String,
+			ScrapedAt: nullTimePtr(histPrice.ScrapedAt),
+		}
+	}
+	return historicalPrices
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func toCoreBars(dbBars []db.PriceBar) []core.Bar {
+	return lo.Map(dbBars, func(b db.PriceBar, _ int) core.Bar {
+		return core.Bar{
+			Start:    b.Start,
+			Open:     b.Open,
+			High:     b.High,
+			Low:      b.Low,
+			Close:    b.Close,
+			Currency: b.Currency,
+			Count:    b.Count,
+		}
+	})
 }
@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PriceBarInterval1h = "1h"
+	PriceBarInterval1d = "1d"
+	PriceBarInterval1w = "1w"
+)
+
+// PriceBarIntervals are the intervals bars are kept in.
+var PriceBarIntervals = []string{PriceBarInterval1h, PriceBarInterval1d, PriceBarInterval1w}
+
+// PriceBar is the open, high, low and close of a symbol's prices in one
+// currency over one interval starting at Start.
+type PriceBar struct {
+	SymbolID int64
+	Interval string
+	Currency string
+	Start    time.Time
+	Open     decimal.Decimal
+	High     decimal.Decimal
+	Low      decimal.Decimal
+	Close    decimal.Decimal
+	Count    int
+}
+
+// barStart is the start of the interval t falls in. Days start at midnight in
+// loc and weeks on Monday; hours are UTC hours.
+func barStart(interval string, t time.Time, loc *time.Location) time.Time {
+	switch interval {
+	case PriceBarInterval1h:
+		return t.UTC().Truncate(time.Hour)
+	case PriceBarInterval1w:
+		day := startOfDay(t, loc)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	default:
+		return startOfDay(t, loc)
+	}
+}
+
+// barEnd is the exclusive end of the interval starting at start.
+func barEnd(interval string, start time.Time) time.Time {
+	switch interval {
+	case PriceBarInterval1h:
+		return start.Add(time.Hour)
+	case PriceBarInterval1w:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// symbolLocation is the time zone of the symbol's exchange, or UTC.
+func (r *Repo) symbolLocation(ctx context.Context, symbolID int64) (*time.Location, error) {
+	var timezone sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		`SELECT e.timezone
+		 FROM symbols s
+		 LEFT JOIN exchanges e ON e.id = s.exchange_id
+		 WHERE s.id = ?`, symbolID,
+	).Scan(&timezone)
+	if err != nil {
+		return nil, err
+	}
+	if !timezone.Valid {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(timezone.String)
+}
+
+// downsampledBefore is the instant before which DownsamplePrices may have
+// thinned out the symbol's prices, or the zero time if it never has.
+func (r *Repo) downsampledBefore(ctx context.Context, symbolID int64) (time.Time, error) {
+	var before sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		`SELECT downsampled_before FROM symbols WHERE id = ?`, symbolID,
+	).Scan(&before)
+	return before.Time, err
+}
+
+// RefreshPriceBars recomputes the symbol's bars of every interval that
+// overlaps start through end from the prices stored in them. Where several
+// sources reported the same instant, the highest priority one counts, as in
+// HistoricalPrices.
+//
+// A bar that starts before the symbol was downsampled was aggregated from
+// prices that retention has since removed, so it is kept as it is; such a bar
+// is only added if it does not exist yet. DownsamplePrices cuts at the start of
+// a week, so such a bar has also ended.
+func (r *Repo) RefreshPriceBars(ctx context.Context, symbolID int64, start time.Time, end time.Time) error {
+	loc, err := r.symbolLocation(ctx, symbolID)
+	if err != nil {
+		return fmt.Errorf("error getting time zone of symbol %v: %w", symbolID, err)
+	}
+	downsampledBefore, err := r.downsampledBefore(ctx, symbolID)
+	if err != nil {
+		return fmt.Errorf("error getting downsampling cutoff of symbol %v: %w", symbolID, err)
+	}
+
+	type span struct{ start, end time.Time }
+	spans := map[string]span{}
+	from, to := start, end
+	for _, interval := range PriceBarIntervals {
+		s := span{barStart(interval, start, loc), barEnd(interval, barStart(interval, end, loc))}
+		spans[interval] = s
+		from = minTime(from, s.start)
+		to = maxTime(to, s.end)
+	}
+
+	prices, err := r.HistoricalPrices(ctx, symbolID, from, to)
+	if err != nil {
+		return fmt.Errorf("error getting prices: %w", err)
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, interval := range PriceBarIntervals {
+		s := spans[interval]
+		_, err := tx.ExecContext(ctx,
+			`DELETE FROM price_bars
+			 WHERE symbol_id = ? AND interval = ? AND start >= ? AND start < ?`,
+			symbolID, interval, maxTime(s.start, downsampledBefore).UTC(), s.end.UTC())
+		if err != nil {
+			return fmt.Errorf("error deleting %v bars: %w", interval, err)
+		}
+
+		type barKey struct {
+			currency string
+			start    time.Time
+		}
+		bars := map[barKey]*PriceBar{}
+		var keys []barKey
+		for _, p := range prices {
+			if p.Timestamp.Before(s.start) || !p.Timestamp.Before(s.end) {
+				continue
+			}
+			key := barKey{p.Currency, barStart(interval, p.Timestamp, loc).UTC()}
+			bar, ok := bars[key]
+			if !ok {
+				bar = &PriceBar{SymbolID: symbolID, Interval: interval, Currency: p.Currency, Start: key.start, Open: p.Price, High: p.Price, Low: p.Price}
+				bars[key] = bar
+				keys = append(keys, key)
+			}
+			bar.High = decimal.Max(bar.High, p.Price)
+			bar.Low = decimal.Min(bar.Low, p.Price)
+			bar.Close = p.Price
+			bar.Count++
+		}
+		for _, key := range keys {
+			bar := bars[key]
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO price_bars (symbol_id, interval, currency, start, open, high, low, close, count)
+				 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+				 ON CONFLICT (symbol_id, interval, currency, start) DO NOTHING`,
+				bar.SymbolID, bar.Interval, bar.Currency, bar.Start, bar.Open, bar.High, bar.Low, bar.Close, bar.Count)
+			if err != nil {
+				return fmt.Errorf("error inserting %v bar: %w", interval, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// RebuildPriceBars recomputes all of the symbol's bars, except those
+// RefreshPriceBars keeps because they reach before the downsampling cutoff.
+// It reports false if the symbol has no prices.
+func (r *Repo) RebuildPriceBars(ctx context.Context, symbolID int64) (bool, error) {
+	var first, last sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		`SELECT MIN(timestamp), MAX(timestamp) FROM prices WHERE symbol_id = ?`, symbolID,
+	).Scan(&first, &last)
+	if err != nil {
+		return false, err
+	}
+	if !first.Valid {
+		_, err := r.db.ExecContext(ctx, `DELETE FROM price_bars WHERE symbol_id = ?`, symbolID)
+		return false, err
+	}
+	start, err := time.Parse(timestampLayout, first.String)
+	if err != nil {
+		return false, fmt.Errorf("error parsing first price timestamp: %w", err)
+	}
+	end, err := time.Parse(timestampLayout, last.String)
+	if err != nil {
+		return false, fmt.Errorf("error parsing last price timestamp: %w", err)
+	}
+	return true, r.RefreshPriceBars(ctx, symbolID, start, end)
+}
+
+// PriceBars returns the symbol's bars of the interval that start between
+// startDate and endDate, oldest first.
+func (r *Repo) PriceBars(ctx context.Context, symbolID int64, interval string, startDate time.Time, endDate time.Time) ([]PriceBar, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT symbol_id, interval, currency, start, open, high, low, close, count
+		 FROM price_bars
+		 WHERE symbol_id = ? AND interval = ?
+		   AND start BETWEEN ? AND ?
+		 ORDER BY start, currency`, symbolID, interval, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bars []PriceBar
+	for rows.Next() {
+		var b PriceBar
+		if err := rows.Scan(&b.SymbolID, &b.Interval, &b.Currency, &b.Start, &b.Open, &b.High, &b.Low, &b.Close, &b.Count); err != nil {
+			return nil, fmt.Errorf("error scanning price bar: %w", err)
+		}
+		bars = append(bars, b)
+	}
+	return bars, rows.Err()
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
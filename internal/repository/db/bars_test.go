@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRefreshPriceBars(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	id := insertTestSymbol(t, r, "A", "XETR")
+	// Out of time order, so open and close must come from the timestamps.
+	for _, p := range []struct{ price, currency, timestamp string }{
+		{"15", "EUR", "2026-10-05T15:00:00Z"},
+		{"11", "EUR", "2026-10-05T08:00:00Z"},
+		{"10", "EUR", "2026-10-04T22:30:00Z"}, // Monday 00:30 in Berlin
+		{"12", "EUR", "2026-10-04T21:30:00Z"}, // Sunday 23:30 in Berlin
+		{"20", "USD", "2026-10-05T09:00:00Z"},
+	} {
+		err := r.InsertPrice(ctx, Price{
+			SymbolID:  id,
+			Price:     decimal.RequireFromString(p.price),
+			Currency:  p.currency,
+			Timestamp: mustParseTime(t, p.timestamp),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.RefreshPriceBars(ctx, id, mustParseTime(t, "2026-10-04T21:30:00Z"), mustParseTime(t, "2026-10-05T15:00:00Z")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		interval string
+		want     []string
+	}{
+		{PriceBarInterval1d, []string{
+			"2026-10-03T22:00:00Z EUR 12 12 12 12 1",
+			"2026-10-04T22:00:00Z EUR 10 15 10 15 3",
+			"2026-10-04T22:00:00Z USD 20 20 20 20 1",
+		}},
+		{PriceBarInterval1w, []string{
+			"2026-09-27T22:00:00Z EUR 12 12 12 12 1",
+			"2026-10-04T22:00:00Z EUR 10 15 10 15 3",
+			"2026-10-04T22:00:00Z USD 20 20 20 20 1",
+		}},
+		{PriceBarInterval1h, []string{
+			"2026-10-04T21:00:00Z EUR 12 12 12 12 1",
+			"2026-10-04T22:00:00Z EUR 10 10 10 10 1",
+			"2026-10-05T08:00:00Z EUR 11 11 11 11 1",
+			"2026-10-05T09:00:00Z USD 20 20 20 20 1",
+			"2026-10-05T15:00:00Z EUR 15 15 15 15 1",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			bars, err := r.PriceBars(ctx, id, tt.interval, mustParseTime(t, "2026-09-01T00:00:00Z"), mustParseTime(t, "2026-11-01T00:00:00Z"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, b := range bars {
+				got = append(got, fmt.Sprintf("%v %v %v %v %v %v %v",
+					b.Start.UTC().Format(time.RFC3339), b.Currency, b.Open, b.High, b.Low, b.Close, b.Count))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -30,9 +33,46 @@ func Open(connStringer ConnectionStringer) (*sql.DB, error) {
 	return db, nil
 }
 
+// querier is what both *sql.DB and *sql.Tx offer, so a Repo can run its
+// queries on either.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 // Repo owns the SQL. Callers only ever see the model types in models.go.
 type Repo struct {
-	db *sql.DB
+	db querier
+	// conn begins the transactions of methods that need one. It is nil in the
+	// Repo that InTx hands out, where tx is the transaction instead.
+	conn *sql.DB
+	tx   *sql.Tx
+}
+
+// transaction is what a method that needs a transaction runs its statements
+// on, and commits or rolls back when it is done.
+type transaction interface {
+	querier
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	Commit() error
+	Rollback() error
+}
+
+// ambientTx is the transaction of InTx as seen by a method called inside it.
+// Only InTx commits or rolls it back.
+type ambientTx struct{ *sql.Tx }
+
+func (ambientTx) Commit() error   { return nil }
+func (ambientTx) Rollback() error { return nil }
+
+// begin starts the transaction of a method that needs one, or joins the one
+// of InTx if r was handed out by it.
+func (r *Repo) begin(ctx context.Context) (transaction, error) {
+	if r.tx != nil {
+		return ambientTx{r.tx}, nil
+	}
+	return r.conn.BeginTx(ctx, nil)
 }
 
 func OpenRepo(connStringer ConnectionStringer) (*Repo, error) {
@@ -40,5 +80,46 @@ func OpenRepo(connStringer ConnectionStringer) (*Repo, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Repo{db: conn}, nil
+	return &Repo{db: conn, conn: conn}, nil
+}
+
+// InTx runs fn with a Repo whose queries all belong to one transaction, which
+// is committed if fn returns nil and rolled back otherwise. Methods that need
+// a transaction of their own join it instead, as does a nested InTx.
+func (r *Repo) InTx(ctx context.Context, fn func(tx *Repo) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(&Repo{db: tx, tx: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// timestampLayout is how the connection writes a time.Time, always in UTC. A
+// stored timestamp therefore compares as text in time order with a time.Time
+// parameter, or with a value formatted by formatTimestamp.
+const timestampLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
+
+// jsonArray encodes values for a json_each(?) table, which lets a query take
+// a list of any length as a single parameter: `WHERE id IN (SELECT value FROM
+// json_each(?))`.
+func jsonArray[T any](values []T) (string, error) {
+	if values == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("error encoding query list: %w", err)
+	}
+	return string(b), nil
 }
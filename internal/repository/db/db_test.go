@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/config"
+	"github.com/shopspring/decimal"
+)
+
+// newTestRepo opens a migrated database in a temporary directory.
+func newTestRepo(t *testing.T) *Repo {
+	t.Helper()
+	cfg := &config.Config{DbConnStr: filepath.Join(t.TempDir(), "stonks.db")}
+	conn, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := Migrate("up", conn, 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	repo, err := OpenRepo(cfg)
+	if err != nil {
+		t.Fatalf("open repo: %v", err)
+	}
+	return repo
+}
+
+// insertTestSymbol adds an active symbol on exchangeID, or on none if empty.
+func insertTestSymbol(t *testing.T, r *Repo, ticker string, exchangeID string) int64 {
+	t.Helper()
+	id, err := r.InsertSymbol(context.Background(), Symbol{
+		Symbol:     ticker,
+		Isin:       ticker,
+		Active:     true,
+		ExchangeID: sql.NullString{String: exchangeID, Valid: exchangeID != ""},
+	})
+	if err != nil {
+		t.Fatalf("insert symbol: %v", err)
+	}
+	return id
+}
+
+// insertTestPrice stores a EUR price at an RFC 3339 timestamp, from sourceID
+// or from no source if empty.
+func insertTestPrice(t *testing.T, r *Repo, symbolID int64, price string, timestamp string, sourceID string) {
+	t.Helper()
+	err := r.InsertPrice(context.Background(), Price{
+		SymbolID:  symbolID,
+		Price:     decimal.RequireFromString(price),
+		Currency:  "EUR",
+		Timestamp: mustParseTime(t, timestamp),
+		SourceID:  sql.NullString{String: sourceID, Valid: sourceID != ""},
+	})
+	if err != nil {
+		t.Fatalf("insert price: %v", err)
+	}
+}
+
+func mustParseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	ts, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("parse %q: %v", value, err)
+	}
+	return ts
+}
+
+// Methods that need a transaction join the one of InTx, and are rolled back
+// with it.
+func TestInTxJoinsTransaction(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	id := insertTestSymbol(t, r, "A", "")
+	insertTestPrice(t, r, id, "1", "2026-10-05T10:00:00Z", "")
+
+	rollback := errors.New("rollback")
+	err := r.InTx(ctx, func(tx *Repo) error {
+		if err := tx.RefreshPriceBars(ctx, id, mustParseTime(t, "2026-10-05T10:00:00Z"), mustParseTime(t, "2026-10-05T10:00:00Z")); err != nil {
+			return err
+		}
+		if _, err := tx.InsertHistoricalPrices(ctx, []Price{{SymbolID: id, Price: decimal.NewFromInt(2), Currency: "EUR", Timestamp: mustParseTime(t, "2026-10-05T11:00:00Z")}}); err != nil {
+			return err
+		}
+		if err := tx.InsertExchangeRates(ctx, []ExchangeRate{{Currency: "USD", Date: mustParseTime(t, "2026-10-05T00:00:00Z"), Rate: decimal.NewFromInt(1)}}); err != nil {
+			return err
+		}
+		if deleted, err := tx.DeleteSymbol(ctx, id); err != nil || !deleted {
+			return fmt.Errorf("delete symbol: %v %w", deleted, err)
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("got %v, want the rollback error", err)
+	}
+	if _, err := r.SymbolByID(ctx, id); err != nil {
+		t.Errorf("symbol was deleted despite the rollback: %v", err)
+	}
+	if got := storedPrices(t, r, id); len(got) != 1 {
+		t.Errorf("got prices %v, want only the first", got)
+	}
+}
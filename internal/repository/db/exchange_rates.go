@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// dateLayout is how exchange_rates.date is stored. The column is plain TEXT
+// rather than DATE so the driver hands it back as a string instead of trying to
+// parse it as a timestamp.
+const dateLayout = "2006-01-02"
+
+// InsertExchangeRates upserts rates in a single transaction. The full ECB
+// history is a few hundred thousand rows, which is far too slow to commit one
+// by one.
+func (r *Repo) InsertExchangeRates(ctx context.Context, rates []ExchangeRate) error {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO exchange_rates (currency, date, rate)
+		 VALUES (?, ?, ?)
+		 ON CONFLICT (currency, date) DO UPDATE
+		 SET rate = EXCLUDED.rate`)
+	if err != nil {
+		return fmt.Errorf("error preparing exchange rate insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.ExecContext(ctx, rate.Currency, rate.Date.Format(dateLayout), rate.Rate); err != nil {
+			return fmt.Errorf("error inserting exchange rate %v on %v: %w", rate.Currency, rate.Date.Format(dateLayout), err)
+		}
+	}
+	return tx.Commit()
+}
+
+// LatestExchangeRate returns the most recent stored rate for currency.
+func (r *Repo) LatestExchangeRate(ctx context.Context, currency string) (ExchangeRate, error) {
+	var currencyCode, date string
+	var rate decimal.Decimal
+	err := r.db.QueryRowContext(ctx,
+		`SELECT currency, date, rate
+		 FROM exchange_rates
+		 WHERE currency = ?
+		 ORDER BY date DESC
+		 LIMIT 1`, currency,
+	).Scan(&currencyCode, &date, &rate)
+	if err != nil {
+		return ExchangeRate{}, err
+	}
+	return exchangeRate(currencyCode, date, rate)
+}
+
+// ExchangeRateDates returns the dates of the earliest and latest stored rates,
+// or zero times when the table is empty.
+func (r *Repo) ExchangeRateDates(ctx context.Context) (time.Time, time.Time, error) {
+	var earliest, latest sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		`SELECT MIN(date), MAX(date) FROM exchange_rates`,
+	).Scan(&earliest, &latest)
+	if err != nil || !earliest.Valid || !latest.Valid {
+		return time.Time{}, time.Time{}, err
+	}
+	earliestDate, err := time.Parse(dateLayout, earliest.String)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("error parsing exchange rate date %q: %w", earliest.String, err)
+	}
+	latestDate, err := time.Parse(dateLayout, latest.String)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("error parsing exchange rate date %q: %w", latest.String, err)
+	}
+	return earliestDate, latestDate, nil
+}
+
+func exchangeRate(currency string, date string, rate decimal.Decimal) (ExchangeRate, error) {
+	parsed, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return ExchangeRate{}, fmt.Errorf("error parsing exchange rate date %q: %w", date, err)
+	}
+	return ExchangeRate{Currency: currency, Date: parsed, Rate: rate}, nil
+}
+
+// ExchangeRates returns the stored rates for currency dated between start and
+// end, plus the last rate before start, so every day in the range has a rate
+// to fall back on. Rates are dated in UTC, so start and end are compared by
+// their UTC date. Rates are ordered oldest first.
+func (r *Repo) ExchangeRates(ctx context.Context, currency string, start time.Time, end time.Time) ([]ExchangeRate, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT currency, date, rate
+		 FROM exchange_rates
+		 WHERE currency = ?1
+		   AND date >= COALESCE(
+		       (SELECT MAX(date) FROM exchange_rates WHERE currency = ?1 AND date <= ?2),
+		       ?2)
+		   AND date <= ?3
+		 ORDER BY date ASC`, currency, start.UTC().Format(dateLayout), end.UTC().Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []ExchangeRate
+	for rows.Next() {
+		var currencyCode, date string
+		var rate decimal.Decimal
+		if err := rows.Scan(&currencyCode, &date, &rate); err != nil {
+			return nil, fmt.Errorf("error scanning exchange rate: %w", err)
+		}
+		parsed, err := exchangeRate(currencyCode, date, rate)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, parsed)
+	}
+	return rates, rows.Err()
+}
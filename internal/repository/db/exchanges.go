@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+func (r *Repo) Exchanges(ctx context.Context) ([]Exchange, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name, timezone FROM exchanges ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exchanges []Exchange
+	for rows.Next() {
+		var e Exchange
+		if err := rows.Scan(&e.ID, &e.Name, &e.Timezone); err != nil {
+			return nil, fmt.Errorf("error scanning exchange: %w", err)
+		}
+		exchanges = append(exchanges, e)
+	}
+	return exchanges, rows.Err()
+}
+
+func (r *Repo) ExchangeByID(ctx context.Context, id string) (Exchange, error) {
+	var e Exchange
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, name, timezone FROM exchanges WHERE id = ?`, id,
+	).Scan(&e.ID, &e.Name, &e.Timezone)
+	return e, err
+}
@@ -1,9 +1,13 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"fmt"
+	iofs "io/fs"
+	"path"
+	"time"
 
 	"github.com/pressly/goose/v3"
 )
@@ -11,13 +15,111 @@ import (
 //go:embed migrations/*.sql
 var fs embed.FS
 
-func Migrate(direction string, db *sql.DB) error {
+// Migrate migrates db in direction:
+//
+//	up      apply every pending migration
+//	down    roll back the latest migration
+//	redo    roll back the latest migration and apply it again
+//	up-to   apply the pending migrations up to and including version
+//	status  log which migrations are applied; MigrationStatus returns it as data
+//
+// version is only read by up-to.
+func Migrate(direction string, db *sql.DB, version int64) error {
 	goose.SetBaseFS(fs)
 	if err := goose.SetDialect("sqlite"); err != nil {
 		return fmt.Errorf("error setting dialect: %w", err)
 	}
-	if err := goose.Up(db, "migrations"); err != nil {
-		return fmt.Errorf("error doing up migration: %w", err)
+	var err error
+	switch direction {
+	case "up":
+		err = goose.Up(db, "migrations")
+	case "down":
+		err = goose.Down(db, "migrations")
+	case "redo":
+		err = goose.Redo(db, "migrations")
+	case "up-to":
+		err = goose.UpTo(db, "migrations", version)
+	case "status":
+		err = goose.Status(db, "migrations")
+	default:
+		return fmt.Errorf("unknown migration direction %q, want up, down, redo, up-to or status", direction)
+	}
+	if err != nil {
+		return fmt.Errorf("error doing %v migration: %w", direction, err)
 	}
 	return nil
 }
+
+// MigrationState is whether one migration has been applied to the database.
+type MigrationState struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// MigrationStatus lists every migration, oldest first, and whether it has
+// been applied.
+func MigrationStatus(ctx context.Context, db *sql.DB) ([]MigrationState, error) {
+	provider, err := newProvider(db)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting migration status: %w", err)
+	}
+	states := make([]MigrationState, 0, len(statuses))
+	for _, status := range statuses {
+		state := MigrationState{
+			Version: status.Source.Version,
+			Name:    path.Base(status.Source.Path),
+			Applied: status.State == goose.StateApplied,
+		}
+		if state.Applied {
+			appliedAt := status.AppliedAt.UTC()
+			state.AppliedAt = &appliedAt
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// SchemaVersion is the version of the latest migration applied to the
+// database, and of the latest one this build has.
+type SchemaVersion struct {
+	Current int64 `json:"current"`
+	Latest  int64 `json:"latest"`
+}
+
+// Pending reports whether migrations are waiting to be applied.
+func (v SchemaVersion) Pending() bool {
+	return v.Current < v.Latest
+}
+
+// GetSchemaVersion reads db's schema version.
+func GetSchemaVersion(ctx context.Context, db *sql.DB) (SchemaVersion, error) {
+	provider, err := newProvider(db)
+	if err != nil {
+		return SchemaVersion{}, err
+	}
+	current, latest, err := provider.GetVersions(ctx)
+	if err != nil {
+		return SchemaVersion{}, fmt.Errorf("error getting schema version: %w", err)
+	}
+	return SchemaVersion{Current: current, Latest: latest}, nil
+}
+
+// newProvider reads the embedded migrations. The provider must not be
+// closed, since that would close db.
+func newProvider(db *sql.DB) (*goose.Provider, error) {
+	migrations, err := iofs.Sub(fs, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, migrations)
+	if err != nil {
+		return nil, fmt.Errorf("error creating migration provider: %w", err)
+	}
+	return provider, nil
+}
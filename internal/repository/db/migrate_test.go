@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bjarke-xyz/stonks/internal/config"
+)
+
+// appliedVersions lists the applied migrations, checking MigrationStatus
+// agrees with GetSchemaVersion along the way.
+func appliedVersions(t *testing.T, conn *sql.DB) []int64 {
+	t.Helper()
+	ctx := context.Background()
+	states, err := MigrationStatus(ctx, conn)
+	if err != nil {
+		t.Fatalf("migration status: %v", err)
+	}
+	var applied []int64
+	for i, state := range states {
+		if i > 0 && state.Version <= states[i-1].Version {
+			t.Errorf("migration %v listed after %v", state.Version, states[i-1].Version)
+		}
+		if !strings.HasSuffix(state.Name, ".sql") || strings.Contains(state.Name, "/") {
+			t.Errorf("migration name %q, want a bare file name", state.Name)
+		}
+		if state.Applied != (state.AppliedAt != nil) {
+			t.Errorf("migration %v: applied %v but applied at %v", state.Version, state.Applied, state.AppliedAt)
+		}
+		if state.Applied {
+			applied = append(applied, state.Version)
+		}
+	}
+	version, err := GetSchemaVersion(ctx, conn)
+	if err != nil {
+		t.Fatalf("schema version: %v", err)
+	}
+	var current int64
+	if len(applied) > 0 {
+		current = applied[len(applied)-1]
+	}
+	if version.Current != current || version.Latest != states[len(states)-1].Version {
+		t.Errorf("schema version %+v, want current %v and latest %v", version, current, states[len(states)-1].Version)
+	}
+	return applied
+}
+
+func TestMigrate(t *testing.T) {
+	cfg := &config.Config{DbConnStr: filepath.Join(t.TempDir(), "stonks.db")}
+	conn, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	states, err := MigrationStatus(context.Background(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := int64(len(states))
+
+	steps := []struct {
+		direction   string
+		version     int64
+		wantApplied int64
+	}{
+		{"status", 0, 0},
+		{"up-to", 3, 3},
+		{"up", 0, latest},
+		{"down", 0, latest - 1},
+		{"redo", 0, latest - 1},
+		{"up", 0, latest},
+		{"redo", 0, latest},
+	}
+	for _, step := range steps {
+		if err := Migrate(step.direction, conn, step.version); err != nil {
+			t.Fatalf("%v: %v", step.direction, err)
+		}
+		applied := appliedVersions(t, conn)
+		if int64(len(applied)) != step.wantApplied {
+			t.Fatalf("after %v: %v migrations applied, want %v", step.direction, len(applied), step.wantApplied)
+		}
+		for i, version := range applied {
+			if version != int64(i+1) {
+				t.Fatalf("after %v: applied %v, want the first %v in order", step.direction, applied, step.wantApplied)
+			}
+		}
+	}
+
+	if err := Migrate("sideways", conn, 0); err == nil || !strings.Contains(err.Error(), "unknown migration direction") {
+		t.Errorf("unknown direction: got %v", err)
+	}
+}
+
+// Every migration can be rolled back and applied again, leaving a working
+// schema.
+func TestMigrateRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{DbConnStr: filepath.Join(t.TempDir(), "stonks.db")}
+	conn, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := Migrate("up", conn, 0); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	for applied := appliedVersions(t, conn); len(applied) > 0; applied = appliedVersions(t, conn) {
+		if err := Migrate("down", conn, 0); err != nil {
+			t.Fatalf("rolling back %v: %v", applied[len(applied)-1], err)
+		}
+	}
+	if err := Migrate("up", conn, 0); err != nil {
+		t.Fatalf("up again: %v", err)
+	}
+	if version, err := GetSchemaVersion(ctx, conn); err != nil || version.Pending() {
+		t.Fatalf("schema version %+v (%v), want none pending", version, err)
+	}
+
+	r, err := OpenRepo(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := insertTestSymbol(t, r, "SAP", "")
+	insertTestPrice(t, r, id, "100", "2026-07-06T10:00:00Z", "")
+	symbols, err := r.SearchSymbols(ctx, "sap", 10)
+	if err != nil || len(symbols) != 1 {
+		t.Fatalf("search after the round trip: %v (%v), want SAP", symbols, err)
+	}
+	if _, err := r.LatestPrice(ctx, id); err != nil {
+		t.Errorf("latest price after the round trip: %v", err)
+	}
+}
@@ -41,4 +41,10 @@ CREATE TABLE IF NOT EXISTS symbol_sources (
 -- test data:
 -- insert into symbols (symbol, name) values ('EUNL', 'iShares Core MSCI World UCITS ETF');
 -- insert into scraping_sources (id, name, base_url ) values ('BORSFRA', 'Börse Frankfurt', 'https://www.boerse-frankfurt.de');
--- insert into symbol_sources (symbol_id, source_id, scrape_url ) values (1, 'BORSFRA', 'https://api.boerse-frankfurt.de/v1/data/price_information/single?isin=IE00B4L5Y983&mic=XETR');
\ No newline at end of file
+-- insert into symbol_sources (symbol_id, source_id, scrape_url ) values (1, 'BORSFRA', 'https://api.boerse-frankfurt.de/v1/data/price_information/single?isin=IE00B4L5Y983&mic=XETR');
+
+-- +goose Down
+DROP TABLE IF EXISTS symbol_sources;
+DROP TABLE IF EXISTS scraping_sources;
+DROP TABLE IF EXISTS prices;
+DROP TABLE IF EXISTS symbols;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- ECB euro reference rates: how many units of currency one euro buys on date.
+-- Any other pair is triangulated through EUR, so EUR itself is never stored.
+CREATE TABLE IF NOT EXISTS exchange_rates(
+    currency TEXT NOT NULL,  -- ISO 4217 code (e.g. USD, DKK)
+    date TEXT NOT NULL,  -- ECB reference date, YYYY-MM-DD
+    rate NUMERIC NOT NULL,  -- Units of currency per 1 EUR
+    PRIMARY KEY (currency, date)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS exchange_rates;
@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS scrape_runs(
+    id INTEGER PRIMARY KEY,  -- Auto-incrementing ID
+    trigger TEXT NOT NULL,  -- What started the run (e.g. scheduler, api)
+    status TEXT NOT NULL,  -- pending, running, succeeded, failed or skipped
+    started_at DATETIME NOT NULL,  -- When the run was requested
+    finished_at DATETIME,  -- When the run ended, NULL while pending or running
+    error TEXT,  -- Why the run failed or was skipped
+    prices_inserted INTEGER NOT NULL DEFAULT 0  -- Prices stored by the run so far
+);
+CREATE INDEX idx_scrape_runs_started_at ON scrape_runs(started_at);
+
+CREATE TABLE IF NOT EXISTS scrape_run_results(
+    id INTEGER PRIMARY KEY,  -- Auto-incrementing ID
+    run_id INTEGER NOT NULL,  -- Foreign key referencing scrape_runs.id
+    symbol_id INTEGER NOT NULL,  -- Foreign key referencing symbols.id
+    source_id TEXT NOT NULL,  -- Foreign key referencing scraping_sources.id
+    status TEXT NOT NULL,  -- succeeded or failed
+    error TEXT,  -- Why scraping or storing failed
+    price NUMERIC,  -- Scraped price, NULL on failure
+    currency TEXT,  -- Scraped currency, NULL on failure
+    timestamp DATETIME,  -- Scraped price timestamp, NULL on failure
+    scraped_at DATETIME NOT NULL,  -- When this symbol-source was scraped
+    FOREIGN KEY (run_id) REFERENCES scrape_runs(id) ON DELETE CASCADE,
+    FOREIGN KEY (symbol_id) REFERENCES symbols(id) ON DELETE CASCADE,
+    FOREIGN KEY (source_id) REFERENCES scraping_sources(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_scrape_run_results_run_id ON scrape_run_results(run_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS scrape_run_results;
+DROP TABLE IF EXISTS scrape_runs;
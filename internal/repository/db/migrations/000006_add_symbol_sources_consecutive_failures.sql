@@ -0,0 +1,7 @@
+-- +goose Up
+-- Scrapes of this symbol-source that have failed in a row since the last
+-- success. Past SCRAPE_MAX_CONSECUTIVE_FAILURES the source is deactivated.
+ALTER TABLE symbol_sources ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE symbol_sources DROP COLUMN consecutive_failures;
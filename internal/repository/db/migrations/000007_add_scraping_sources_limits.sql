@@ -0,0 +1,9 @@
+-- +goose Up
+-- How hard a scrape pass may hit each source: at most max_concurrency requests
+-- in flight, started at most requests_per_second apart. NULL means no rate limit.
+ALTER TABLE scraping_sources ADD COLUMN max_concurrency INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE scraping_sources ADD COLUMN requests_per_second REAL;
+
+-- +goose Down
+ALTER TABLE scraping_sources DROP COLUMN requests_per_second;
+ALTER TABLE scraping_sources DROP COLUMN max_concurrency;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Order in which a symbol's sources are tried, lowest first. The scraper only
+-- falls back to the next source when one fails or returns a stale price.
+ALTER TABLE symbol_sources ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+
+-- The scraping source that supplied a price. NULL for rows from before this.
+ALTER TABLE prices ADD COLUMN source_id TEXT REFERENCES scraping_sources(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE prices DROP COLUMN source_id;
+ALTER TABLE symbol_sources DROP COLUMN priority;
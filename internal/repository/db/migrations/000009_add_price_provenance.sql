@@ -0,0 +1,45 @@
+-- Prices carry where they came from, and the uniqueness rule accounts for it,
+-- so two providers disagreeing about the same instant no longer overwrite each
+-- other. SQLite cannot alter a constraint, so the table is rebuilt. Rows from
+-- before source_id existed keep NULL, which the unique index folds to '' so
+-- they still conflict with each other.
+
+-- +goose Up
+CREATE TABLE prices_new (
+    id INTEGER PRIMARY KEY,  -- Auto-incrementing ID
+    symbol_id INTEGER NOT NULL,  -- Foreign key referencing symbols.id
+    price NUMERIC NOT NULL,  -- Price of the stock/ETF
+    currency TEXT NOT NULL,  -- Currency (e.g., USD, EUR)
+    timestamp DATETIME NOT NULL,  -- Exact time of the price capture
+    source_id TEXT,  -- Scraping source that supplied the price, NULL if unknown
+    scraped_at DATETIME,  -- When the price was scraped, NULL if unknown
+    FOREIGN KEY (symbol_id) REFERENCES symbols(id) ON DELETE CASCADE,
+    FOREIGN KEY (source_id) REFERENCES scraping_sources(id) ON DELETE SET NULL
+);
+INSERT INTO prices_new (id, symbol_id, price, currency, timestamp, source_id)
+SELECT id, symbol_id, price, currency, timestamp, source_id FROM prices;
+DROP TABLE prices;
+ALTER TABLE prices_new RENAME TO prices;
+
+CREATE INDEX idx_prices_timestamp ON prices(timestamp);
+CREATE UNIQUE INDEX idx_prices_symbol_currency_timestamp_source
+    ON prices(symbol_id, currency, timestamp, COALESCE(source_id, ''));
+
+-- +goose Down
+-- Keeps the latest row of each (symbol_id, currency, timestamp) group.
+CREATE TABLE prices_old (
+    id INTEGER PRIMARY KEY,
+    symbol_id INTEGER NOT NULL,
+    price NUMERIC NOT NULL,
+    currency TEXT NOT NULL,
+    timestamp DATETIME NOT NULL,
+    source_id TEXT REFERENCES scraping_sources(id) ON DELETE SET NULL,
+    UNIQUE(symbol_id, currency, timestamp),
+    FOREIGN KEY (symbol_id) REFERENCES symbols(id) ON DELETE CASCADE
+);
+INSERT INTO prices_old (id, symbol_id, price, currency, timestamp, source_id)
+SELECT id, symbol_id, price, currency, timestamp, source_id FROM prices
+WHERE id IN (SELECT MAX(id) FROM prices GROUP BY symbol_id, currency, timestamp);
+DROP TABLE prices;
+ALTER TABLE prices_old RENAME TO prices;
+CREATE INDEX idx_prices_timestamp ON prices(timestamp);
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Scraped prices that looked wrong enough to hold back from prices until
+-- someone approves or rejects them through the API.
+CREATE TABLE IF NOT EXISTS quarantined_prices(
+    id INTEGER PRIMARY KEY,  -- Auto-incrementing ID
+    symbol_id INTEGER NOT NULL,  -- Foreign key referencing symbols.id
+    source_id TEXT NOT NULL,  -- Scraping source that supplied the price
+    price NUMERIC NOT NULL,  -- Scraped price
+    currency TEXT NOT NULL,  -- Scraped currency
+    timestamp DATETIME NOT NULL,  -- Scraped price timestamp
+    scraped_at DATETIME NOT NULL,  -- When the price was scraped
+    reason TEXT NOT NULL,  -- Which check the price failed
+    status TEXT NOT NULL DEFAULT 'pending',  -- pending, approved or rejected
+    reviewed_at DATETIME,  -- When the price was approved or rejected
+    UNIQUE(symbol_id, source_id, currency, timestamp),
+    FOREIGN KEY (symbol_id) REFERENCES symbols(id) ON DELETE CASCADE,
+    FOREIGN KEY (source_id) REFERENCES scraping_sources(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_quarantined_prices_status ON quarantined_prices(status);
+
+-- +goose Down
+DROP TABLE IF EXISTS quarantined_prices;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- An inactive symbol keeps its prices and sources but is no longer scraped.
+ALTER TABLE symbols ADD COLUMN active BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- +goose Down
+ALTER TABLE symbols DROP COLUMN active;
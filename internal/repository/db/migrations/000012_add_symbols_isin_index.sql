@@ -0,0 +1,6 @@
+-- +goose Up
+-- /quote/{isin} looks symbols up by ISIN.
+CREATE INDEX idx_symbols_isin ON symbols(isin);
+
+-- +goose Down
+DROP INDEX idx_symbols_isin;
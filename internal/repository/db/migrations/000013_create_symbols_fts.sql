@@ -0,0 +1,37 @@
+-- Full-text index of symbols for /api/symbols/search, kept in step with the
+-- symbols table by triggers. '.' and '-' are part of a token so tickers such as
+-- BRK.B stay whole, and diacritics are folded so "borse" finds "Börse".
+
+-- +goose Up
+CREATE VIRTUAL TABLE symbols_fts USING fts5(
+    symbol, name, isin,
+    content = 'symbols',
+    content_rowid = 'id',
+    tokenize = "unicode61 remove_diacritics 2 tokenchars '.-'"
+);
+INSERT INTO symbols_fts(symbols_fts) VALUES ('rebuild');
+
+-- +goose StatementBegin
+CREATE TRIGGER symbols_fts_after_insert AFTER INSERT ON symbols BEGIN
+    INSERT INTO symbols_fts(rowid, symbol, name, isin) VALUES (new.id, new.symbol, new.name, new.isin);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER symbols_fts_after_delete AFTER DELETE ON symbols BEGIN
+    INSERT INTO symbols_fts(symbols_fts, rowid, symbol, name, isin) VALUES ('delete', old.id, old.symbol, old.name, old.isin);
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER symbols_fts_after_update AFTER UPDATE OF symbol, name, isin ON symbols BEGIN
+    INSERT INTO symbols_fts(symbols_fts, rowid, symbol, name, isin) VALUES ('delete', old.id, old.symbol, old.name, old.isin);
+    INSERT INTO symbols_fts(rowid, symbol, name, isin) VALUES (new.id, new.symbol, new.name, new.isin);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER symbols_fts_after_update;
+DROP TRIGGER symbols_fts_after_delete;
+DROP TRIGGER symbols_fts_after_insert;
+DROP TABLE symbols_fts;
@@ -0,0 +1,29 @@
+-- Exchanges a symbol can be listed on, identified by their ISO 10383 market
+-- identifier code. A quote's trading day is the calendar day in the exchange's
+-- time zone, so a symbol without an exchange falls back to UTC days.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS exchanges (
+    id TEXT PRIMARY KEY,  -- Market identifier code (e.g., XETR, XNYS)
+    name TEXT NOT NULL,  -- Name of the exchange
+    timezone TEXT NOT NULL  -- IANA time zone of the exchange (e.g., Europe/Berlin)
+);
+
+INSERT INTO exchanges (id, name, timezone) VALUES
+    ('XETR', 'Xetra', 'Europe/Berlin'),
+    ('XFRA', 'Börse Frankfurt', 'Europe/Berlin'),
+    ('XCSE', 'Nasdaq Copenhagen', 'Europe/Copenhagen'),
+    ('XSTO', 'Nasdaq Stockholm', 'Europe/Stockholm'),
+    ('XAMS', 'Euronext Amsterdam', 'Europe/Amsterdam'),
+    ('XPAR', 'Euronext Paris', 'Europe/Paris'),
+    ('XMIL', 'Borsa Italiana', 'Europe/Rome'),
+    ('XSWX', 'SIX Swiss Exchange', 'Europe/Zurich'),
+    ('XLON', 'London Stock Exchange', 'Europe/London'),
+    ('XNYS', 'New York Stock Exchange', 'America/New_York'),
+    ('XNAS', 'Nasdaq', 'America/New_York');
+
+ALTER TABLE symbols ADD COLUMN exchange_id TEXT REFERENCES exchanges(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE symbols DROP COLUMN exchange_id;
+DROP TABLE exchanges;
@@ -0,0 +1,21 @@
+-- OHLC bars aggregated from prices after each scrape, so long ranges need not
+-- read every raw price. Days and weeks are those of the symbol's exchange time
+-- zone, like a quote's trading day; hours are UTC hours.
+
+-- +goose Up
+CREATE TABLE IF NOT EXISTS price_bars(
+    symbol_id INTEGER NOT NULL,  -- Foreign key referencing symbols.id
+    interval TEXT NOT NULL,  -- 1h, 1d or 1w
+    currency TEXT NOT NULL,  -- Currency of the prices in the bar
+    start DATETIME NOT NULL,  -- Start of the bar, in UTC
+    open NUMERIC NOT NULL,  -- First price in the bar
+    high NUMERIC NOT NULL,  -- Highest price in the bar
+    low NUMERIC NOT NULL,  -- Lowest price in the bar
+    close NUMERIC NOT NULL,  -- Last price in the bar
+    count INTEGER NOT NULL,  -- Number of prices in the bar
+    PRIMARY KEY (symbol_id, interval, currency, start),
+    FOREIGN KEY (symbol_id) REFERENCES symbols(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS price_bars;
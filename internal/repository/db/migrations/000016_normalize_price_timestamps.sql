@@ -0,0 +1,24 @@
+-- Stores every price timestamp in UTC, as the connection writes them since
+-- _timezone=UTC. All timestamps then share one offset, so their text sorts in
+-- time order and queries compare the column itself, which lets them use
+-- idx_prices_symbol_timestamp rather than wrapping every row in DATETIME().
+--
+-- Rows written with another offset are rewritten to the driver's layout,
+-- "2006-01-02 15:04:05.999999999-07:00" in UTC. Where that makes a row clash
+-- with one already stored for the same instant and source, OR REPLACE keeps the
+-- rewritten row: both hold the same reading.
+
+-- +goose Up
+UPDATE OR REPLACE prices
+SET timestamp = CASE
+        WHEN strftime('%f', timestamp) LIKE '%.000' THEN DATETIME(timestamp) || '+00:00'
+        ELSE rtrim(strftime('%Y-%m-%d %H:%M:%f', timestamp), '0') || '+00:00'
+    END
+WHERE timestamp NOT LIKE '%+00:00';
+
+CREATE INDEX idx_prices_symbol_timestamp ON prices(symbol_id, timestamp);
+
+-- +goose Down
+-- The original offsets are not restored: they carried no information the UTC
+-- form lacks.
+DROP INDEX idx_prices_symbol_timestamp;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Prices before this instant may have been thinned out by retention, so the
+-- bars that reach before it are no longer rebuilt from the stored prices.
+ALTER TABLE symbols ADD COLUMN downsampled_before DATETIME;
+
+-- +goose Down
+ALTER TABLE symbols DROP COLUMN downsampled_before;
@@ -12,6 +12,15 @@ type Symbol struct {
 	Symbol string
 	Name   sql.NullString
 	Isin   string
+	Active bool
+
+	ExchangeID sql.NullString
+}
+
+type Exchange struct {
+	ID       string
+	Name     string
+	Timezone string
 }
 
 type Price struct {
@@ -20,6 +29,8 @@ type Price struct {
 	Price     decimal.Decimal
 	Currency  string
 	Timestamp time.Time
+	SourceID  sql.NullString
+	ScrapedAt sql.NullTime
 }
 
 type ScrapingSource struct {
@@ -27,6 +38,9 @@ type ScrapingSource struct {
 	Name           string
 	BaseUrl        string
 	AdditionalInfo sql.NullString
+
+	MaxConcurrency    int
+	RequestsPerSecond sql.NullFloat64
 }
 
 type SymbolSource struct {
@@ -36,4 +50,51 @@ type SymbolSource struct {
 	ScrapeUrl   string
 	Active      sql.NullBool
 	LastScraped sql.NullTime
+
+	ConsecutiveFailures int
+	Priority            int
+}
+
+// ExchangeRate is an ECB euro reference rate: one EUR buys Rate units of
+// Currency on Date.
+type ExchangeRate struct {
+	Currency string
+	Date     time.Time
+	Rate     decimal.Decimal
+}
+
+type ScrapeRun struct {
+	ID             int64
+	Trigger        string
+	Status         string
+	StartedAt      time.Time
+	FinishedAt     sql.NullTime
+	Error          sql.NullString
+	PricesInserted int
+}
+
+type ScrapeRunResult struct {
+	ID        int64
+	RunID     int64
+	SymbolID  int64
+	SourceID  string
+	Status    string
+	Error     sql.NullString
+	Price     decimal.NullDecimal
+	Currency  sql.NullString
+	Timestamp sql.NullTime
+	ScrapedAt time.Time
+}
+
+type QuarantinedPrice struct {
+	ID         int64
+	SymbolID   int64
+	SourceID   string
+	Price      decimal.Decimal
+	Currency   string
+	Timestamp  time.Time
+	ScrapedAt  time.Time
+	Reason     string
+	Status     string
+	ReviewedAt sql.NullTime
 }
@@ -2,21 +2,24 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/shopspring/decimal"
 )
 
-// PriceQuote is the latest price for a symbol, alongside today's opening price
-// and the previous day's closing price. Change absolute/percentage are derived
-// from these in the domain layer, not in SQL.
+// PriceQuote is the latest price for a symbol, alongside the opening price of
+// its trading day and the closing price of the previous one. Change
+// absolute/percentage are derived from these in the domain layer, not in SQL.
 type PriceQuote struct {
 	LatestPrice          decimal.Decimal
 	Currency             string
 	Timestamp            time.Time
 	OpeningPrice         decimal.Decimal
 	PreviousClosingPrice decimal.Decimal
+	SourceID             sql.NullString
+	ScrapedAt            sql.NullTime
 }
 
 // HistoricalPrice is a price point without its symbol or row id.
@@ -24,71 +27,255 @@ type HistoricalPrice struct {
 	Price     decimal.Decimal
 	Currency  string
 	Timestamp time.Time
+	SourceID  sql.NullString
+	ScrapedAt sql.NullTime
 }
 
-func (r *Repo) InsertPrice(ctx context.Context, symbolID int64, price decimal.Decimal, currency string, timestamp time.Time) error {
+// InsertPrice upserts price. A price is unique per symbol, currency, timestamp
+// and source, so a second source reporting the same instant adds a row rather
+// than overwriting the first. ID is ignored.
+func (r *Repo) InsertPrice(ctx context.Context, price Price) error {
 	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO prices (symbol_id, price, currency, timestamp)
-		 VALUES (?, ?, ?, ?)
-		 ON CONFLICT (symbol_id, timestamp, currency) DO UPDATE
-		 SET price = EXCLUDED.price, timestamp = EXCLUDED.timestamp`,
-		symbolID, price, currency, timestamp)
+		`INSERT INTO prices (symbol_id, price, currency, timestamp, source_id, scraped_at)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (symbol_id, currency, timestamp, COALESCE(source_id, '')) DO UPDATE
+		 SET price = EXCLUDED.price, scraped_at = EXCLUDED.scraped_at`,
+		price.SymbolID, price.Price, price.Currency, price.Timestamp, price.SourceID, price.ScrapedAt)
 	return err
 }
 
+// UpsertPrice stores the price like InsertPrice, and reports whether it was
+// new rather than an update of one already stored at that instant. An upsert
+// affects one row either way, so the insert and the update are separate
+// statements.
+func (r *Repo) UpsertPrice(ctx context.Context, price Price) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO prices (symbol_id, price, currency, timestamp, source_id, scraped_at)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (symbol_id, currency, timestamp, COALESCE(source_id, '')) DO NOTHING`,
+		price.SymbolID, price.Price, price.Currency, price.Timestamp, price.SourceID, price.ScrapedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n == 1 {
+		return n == 1, err
+	}
+	_, err = r.db.ExecContext(ctx,
+		`UPDATE prices SET price = ?, scraped_at = ?
+		 WHERE symbol_id = ? AND currency = ? AND timestamp = ? AND COALESCE(source_id, '') = COALESCE(?, '')`,
+		price.Price, price.ScrapedAt, price.SymbolID, price.Currency, price.Timestamp, price.SourceID)
+	return false, err
+}
+
+// InsertHistoricalPrices inserts prices in one transaction, leaving any that
+// are already stored untouched, and returns how many were new. IDs are ignored.
+func (r *Repo) InsertHistoricalPrices(ctx context.Context, prices []Price) (int, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	inserted := 0
+	for _, price := range prices {
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO prices (symbol_id, price, currency, timestamp, source_id, scraped_at)
+			 VALUES (?, ?, ?, ?, ?, ?)
+			 ON CONFLICT (symbol_id, currency, timestamp, COALESCE(source_id, '')) DO NOTHING`,
+			price.SymbolID, price.Price, price.Currency, price.Timestamp, price.SourceID, price.ScrapedAt)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		inserted += int(n)
+	}
+	return inserted, tx.Commit()
+}
+
+// Quote returns the symbol's latest price, or sql.ErrNoRows if it has none.
 func (r *Repo) Quote(ctx context.Context, symbolID int64) (PriceQuote, error) {
-	var q PriceQuote
-	err := r.db.QueryRowContext(ctx,
-		`WITH latest_price AS (
-		     SELECT price, currency, timestamp
-		     FROM prices
-		     WHERE symbol_id = ?1
-		     ORDER BY timestamp DESC
-		     LIMIT 1
-		 ),
-		 opening_price AS (
-		     SELECT price AS opening_price
-		     FROM prices
-		     WHERE symbol_id = ?1 AND DATE(timestamp) = DATE('now')
-		     ORDER BY timestamp ASC
-		     LIMIT 1
+	quotes, err := r.Quotes(ctx, []int64{symbolID})
+	if err != nil {
+		return PriceQuote{}, err
+	}
+	q, ok := quotes[symbolID]
+	if !ok {
+		return PriceQuote{}, sql.ErrNoRows
+	}
+	return q, nil
+}
+
+// Quotes returns the latest price of each symbol alongside the opening price
+// of its trading day and the closing price of the trading day before, keyed by
+// symbol ID. Where several sources reported the latest instant, the symbol's
+// highest priority source wins. Symbols without any price are left out.
+//
+// The trading day is the calendar day, in the time zone of the symbol's
+// exchange, that the latest price falls on; a symbol without an exchange uses
+// UTC days. The previous close is the last price before that day began, so it
+// comes from the most recent earlier session however many weekend days or
+// holidays lie in between.
+func (r *Repo) Quotes(ctx context.Context, symbolIDs []int64) (map[int64]PriceQuote, error) {
+	idList, err := jsonArray(symbolIDs)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`WITH latest_timestamp AS (
+		     SELECT p.symbol_id, MAX(p.timestamp) AS timestamp
+		     FROM prices p
+		     WHERE p.symbol_id IN (SELECT value FROM json_each(?))
+		     GROUP BY p.symbol_id
 		 ),
-		 previous_closing_price AS (
-		     SELECT price AS closing_price
-		     FROM prices
-		     WHERE symbol_id = ?1 AND DATE(timestamp) = DATE('now', '-1 day')
-		     ORDER BY timestamp DESC
-		     LIMIT 1
+		 latest_price AS (
+		     SELECT p.symbol_id, p.price, p.currency, p.timestamp, p.source_id, p.scraped_at,
+		            ROW_NUMBER() OVER (
+		                PARTITION BY p.symbol_id
+		                ORDER BY COALESCE(ss.priority, 2147483647), p.id DESC
+		            ) AS rn
+		     FROM prices p
+		     JOIN latest_timestamp lt ON lt.symbol_id = p.symbol_id AND lt.timestamp = p.timestamp
+		     LEFT JOIN symbol_sources ss ON ss.symbol_id = p.symbol_id AND ss.source_id = p.source_id
 		 )
-		 SELECT lp.price, lp.currency, lp.timestamp,
-		        COALESCE(op.opening_price, 0.0),
-		        COALESCE(pc.closing_price, 0.0)
+		 SELECT lp.symbol_id, lp.price, lp.currency, lp.timestamp, lp.source_id, lp.scraped_at, e.timezone
 		 FROM latest_price lp
-		 LEFT JOIN opening_price op ON 1=1
-		 LEFT JOIN previous_closing_price pc ON 1=1`, symbolID,
-	).Scan(&q.LatestPrice, &q.Currency, &q.Timestamp, &q.OpeningPrice, &q.PreviousClosingPrice)
-	return q, err
+		 JOIN symbols s ON s.id = lp.symbol_id
+		 LEFT JOIN exchanges e ON e.id = s.exchange_id
+		 WHERE lp.rn = 1`, idList)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type tradingDay struct {
+		SymbolID int64  `json:"symbolId"`
+		Start    string `json:"start"`
+	}
+	quotes := map[int64]PriceQuote{}
+	var tradingDays []tradingDay
+	locations := map[string]*time.Location{}
+	for rows.Next() {
+		var symbolID int64
+		var q PriceQuote
+		var timezone sql.NullString
+		if err := rows.Scan(&symbolID, &q.LatestPrice, &q.Currency, &q.Timestamp, &q.SourceID, &q.ScrapedAt, &timezone); err != nil {
+			return nil, fmt.Errorf("error scanning quote: %w", err)
+		}
+		loc := time.UTC
+		if timezone.Valid {
+			if loc = locations[timezone.String]; loc == nil {
+				if loc, err = time.LoadLocation(timezone.String); err != nil {
+					return nil, fmt.Errorf("error loading time zone of symbol %v: %w", symbolID, err)
+				}
+				locations[timezone.String] = loc
+			}
+		}
+		quotes[symbolID] = q
+		tradingDays = append(tradingDays, tradingDay{
+			SymbolID: symbolID,
+			Start:    startOfDay(q.Timestamp, loc).UTC().Format(time.DateTime),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(tradingDays) == 0 {
+		return quotes, nil
+	}
+
+	dayList, err := jsonArray(tradingDays)
+	if err != nil {
+		return nil, err
+	}
+	rows, err = r.db.QueryContext(ctx,
+		`WITH trading_day AS (
+		     SELECT json_extract(value, '$.symbolId') AS symbol_id, json_extract(value, '$.start') AS start
+		     FROM json_each(?)
+		 )
+		 SELECT td.symbol_id,
+		        COALESCE((SELECT p.price FROM prices p
+		                  WHERE p.symbol_id = td.symbol_id AND p.timestamp >= td.start
+		                  ORDER BY p.timestamp ASC, p.id ASC
+		                  LIMIT 1), 0.0),
+		        COALESCE((SELECT p.price FROM prices p
+		                  WHERE p.symbol_id = td.symbol_id AND p.timestamp < td.start
+		                  ORDER BY p.timestamp DESC, p.id DESC
+		                  LIMIT 1), 0.0)
+		 FROM trading_day td`, dayList)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var symbolID int64
+		var openingPrice, previousClosingPrice decimal.Decimal
+		if err := rows.Scan(&symbolID, &openingPrice, &previousClosingPrice); err != nil {
+			return nil, fmt.Errorf("error scanning trading day prices: %w", err)
+		}
+		q := quotes[symbolID]
+		q.OpeningPrice = openingPrice
+		q.PreviousClosingPrice = previousClosingPrice
+		quotes[symbolID] = q
+	}
+	return quotes, rows.Err()
 }
 
+// startOfDay is midnight in loc of the day t falls on in loc.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// HistoricalPrices returns one price per timestamp. Where several sources
+// reported the same instant, the symbol's highest priority source wins.
 func (r *Repo) HistoricalPrices(ctx context.Context, symbolID int64, startDate time.Time, endDate time.Time) ([]HistoricalPrice, error) {
+	prices, err := r.HistoricalPricesBySymbols(ctx, []int64{symbolID}, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return prices[symbolID], nil
+}
+
+// HistoricalPricesBySymbols is HistoricalPrices for many symbols in one query,
+// keyed by symbol ID. Symbols without prices in the range are left out.
+func (r *Repo) HistoricalPricesBySymbols(ctx context.Context, symbolIDs []int64, startDate time.Time, endDate time.Time) (map[int64][]HistoricalPrice, error) {
+	idList, err := jsonArray(symbolIDs)
+	if err != nil {
+		return nil, err
+	}
 	rows, err := r.db.QueryContext(ctx,
-		`SELECT price, currency, timestamp
-		 FROM prices
-		 WHERE symbol_id = ?
-		   AND DATETIME(timestamp) BETWEEN DATETIME(?) AND DATETIME(?)
-		 ORDER BY timestamp ASC`, symbolID, startDate, endDate)
+		`SELECT symbol_id, price, currency, timestamp, source_id, scraped_at
+		 FROM (
+		     SELECT p.symbol_id, p.price, p.currency, p.timestamp, p.source_id, p.scraped_at,
+		            ROW_NUMBER() OVER (
+		                PARTITION BY p.symbol_id, p.currency, p.timestamp
+		                ORDER BY COALESCE(ss.priority, 2147483647), p.id DESC
+		            ) AS rn
+		     FROM prices p
+		     LEFT JOIN symbol_sources ss ON ss.symbol_id = p.symbol_id AND ss.source_id = p.source_id
+		     WHERE p.symbol_id IN (SELECT value FROM json_each(?))
+		       AND p.timestamp BETWEEN ? AND ?
+		 )
+		 WHERE rn = 1
+		 ORDER BY symbol_id, timestamp ASC`, idList, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var prices []HistoricalPrice
+	prices := map[int64][]HistoricalPrice{}
 	for rows.Next() {
+		var symbolID int64
 		var p HistoricalPrice
-		if err := rows.Scan(&p.Price, &p.Currency, &p.Timestamp); err != nil {
+		if err := rows.Scan(&symbolID, &p.Price, &p.Currency, &p.Timestamp, &p.SourceID, &p.ScrapedAt); err != nil {
 			return nil, fmt.Errorf("error scanning historical price: %w", err)
 		}
-		prices = append(prices, p)
+		prices[symbolID] = append(prices[symbolID], p)
 	}
 	return prices, rows.Err()
 }
@@ -0,0 +1,244 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bjarke-xyz/stonks/internal/config"
+	"github.com/shopspring/decimal"
+)
+
+func TestQuotesTradingDay(t *testing.T) {
+	type price struct{ price, timestamp string }
+	tests := []struct {
+		name          string
+		exchangeID    string
+		prices        []price
+		latest        string
+		opening       string
+		previousClose string
+	}{
+		{
+			name:       "monday closes on friday",
+			exchangeID: "XETR",
+			prices: []price{
+				{"99", "2026-10-16T09:00:00+02:00"},
+				{"100", "2026-10-16T17:30:00+02:00"},
+				{"105", "2026-10-19T09:00:00+02:00"},
+				{"107", "2026-10-19T12:00:00+02:00"},
+			},
+			latest: "107", opening: "105", previousClose: "100",
+		},
+		{
+			name:       "after the christmas holidays",
+			exchangeID: "XETR",
+			prices: []price{
+				{"50", "2026-12-23T17:30:00+01:00"},
+				{"52", "2026-12-28T09:00:00+01:00"},
+				{"53", "2026-12-28T10:00:00+01:00"},
+			},
+			latest: "53", opening: "52", previousClose: "50",
+		},
+		{
+			// 00:30 in Berlin is still the previous day in UTC.
+			name:       "day starts at midnight in the exchange time zone",
+			exchangeID: "XETR",
+			prices: []price{
+				{"10", "2026-10-19T20:00:00Z"},
+				{"11", "2026-10-19T21:00:00Z"},
+				{"12", "2026-10-19T22:30:00Z"},
+			},
+			latest: "12", opening: "12", previousClose: "11",
+		},
+		{
+			name:       "symbol without exchange uses utc days",
+			exchangeID: "",
+			prices: []price{
+				{"10", "2026-10-19T20:00:00Z"},
+				{"11", "2026-10-19T21:00:00Z"},
+				{"12", "2026-10-19T22:30:00Z"},
+			},
+			latest: "12", opening: "10", previousClose: "0",
+		},
+		{
+			name:       "offsets are compared in time, not as text",
+			exchangeID: "XNYS",
+			prices: []price{
+				{"20", "2026-10-16T15:55:00-04:00"},
+				{"21", "2026-10-19T15:35:00+02:00"},
+				{"22", "2026-10-19T10:00:00-04:00"},
+			},
+			latest: "22", opening: "21", previousClose: "20",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			id := insertTestSymbol(t, r, "A", tt.exchangeID)
+			for _, p := range tt.prices {
+				insertTestPrice(t, r, id, p.price, p.timestamp, "")
+			}
+			q, err := r.Quote(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.LatestPrice.String() != tt.latest || q.OpeningPrice.String() != tt.opening || q.PreviousClosingPrice.String() != tt.previousClose {
+				t.Fatalf("got latest %v opening %v previous close %v, want %v %v %v",
+					q.LatestPrice, q.OpeningPrice, q.PreviousClosingPrice, tt.latest, tt.opening, tt.previousClose)
+			}
+		})
+	}
+}
+
+func TestNormalizePriceTimestampsMigration(t *testing.T) {
+	cfg := &config.Config{DbConnStr: filepath.Join(t.TempDir(), "stonks.db")}
+	conn, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate("up-to", conn, 15); err != nil {
+		t.Fatal(err)
+	}
+	// Rows as the driver wrote them before it converted to UTC.
+	_, err = conn.Exec(`INSERT INTO prices (symbol_id, price, currency, timestamp) VALUES
+		(1, '1', 'EUR', '2026-10-19 09:00:00+02:00'),
+		(1, '2', 'EUR', '2026-10-19 09:30:00.25+02:00'),
+		(1, '3', 'EUR', '2026-10-19 08:00:00+00:00')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate("up", conn, 0); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := conn.Query("SELECT CAST(timestamp AS TEXT) FROM prices ORDER BY price")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var ts string
+		if err := rows.Scan(&ts); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ts)
+	}
+	want := []string{"2026-10-19 07:00:00+00:00", "2026-10-19 07:30:00.25+00:00", "2026-10-19 08:00:00+00:00"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestUpsertPrice(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	id := insertTestSymbol(t, r, "A", "")
+	price := Price{SymbolID: id, Price: decimal.NewFromInt(1), Currency: "EUR", Timestamp: mustParseTime(t, "2026-10-05T10:00:00Z")}
+	for i, want := range []bool{true, false} {
+		inserted, err := r.UpsertPrice(ctx, price)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inserted != want {
+			t.Errorf("upsert %v reported inserted %v, want %v", i, inserted, want)
+		}
+		price.Price = decimal.NewFromInt(2)
+	}
+	q, err := r.Quote(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.LatestPrice.String() != "2" {
+		t.Errorf("got price %v, want the update to 2", q.LatestPrice)
+	}
+}
+
+// Two sources reporting the same instant resolve to the symbol's highest
+// priority one, the lowest number, and a source the symbol no longer uses
+// comes last.
+func TestSameInstantResolvesByPriority(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	id := insertTestSymbol(t, r, "A", "")
+	sourceIDs := map[string]int64{}
+	for source, priority := range map[string]int{"a": 2, "b": 1} {
+		ssID, err := r.InsertSymbolSource(ctx, SymbolSource{SymbolID: id, SourceID: source, Priority: priority})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sourceIDs[source] = ssID
+	}
+	for _, p := range []struct{ price, timestamp, source string }{
+		{"10", "2026-10-05T10:00:00Z", "a"},
+		{"11", "2026-10-05T10:00:00Z", "b"},
+		{"12", "2026-10-05T10:00:00Z", "c"},
+		{"20", "2026-10-05T11:00:00Z", "c"},
+		{"21", "2026-10-05T11:00:00Z", "a"},
+		{"22", "2026-10-05T11:00:00Z", "b"},
+	} {
+		insertTestPrice(t, r, id, p.price, p.timestamp, p.source)
+	}
+
+	check := func(wantHistory []string, wantLatest string) {
+		t.Helper()
+		prices, err := r.HistoricalPrices(ctx, id, mustParseTime(t, "2026-10-05T00:00:00Z"), mustParseTime(t, "2026-10-06T00:00:00Z"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, p := range prices {
+			got = append(got, p.Price.String()+" "+p.SourceID.String)
+		}
+		if strings.Join(got, ",") != strings.Join(wantHistory, ",") {
+			t.Errorf("historical prices %v, want %v", got, wantHistory)
+		}
+		q, err := r.Quote(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := q.LatestPrice.String() + " " + q.SourceID.String; got != wantLatest {
+			t.Errorf("latest price %v, want %v", got, wantLatest)
+		}
+	}
+	check([]string{"11 b", "22 b"}, "22 b")
+
+	source, err := r.SymbolSourceByID(ctx, sourceIDs["a"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	source.Priority = 0
+	if err := r.UpdateSymbolSource(ctx, source); err != nil {
+		t.Fatal(err)
+	}
+	check([]string{"10 a", "21 a"}, "21 a")
+}
+
+func TestHistoricalPricesBySymbols(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	a := insertTestSymbol(t, r, "A", "")
+	b := insertTestSymbol(t, r, "B", "")
+	none := insertTestSymbol(t, r, "C", "")
+	insertTestPrice(t, r, a, "1", "2026-10-05T10:00:00Z", "")
+	insertTestPrice(t, r, a, "2", "2026-10-05T11:00:00Z", "")
+	insertTestPrice(t, r, a, "3", "2026-10-07T11:00:00Z", "")
+	insertTestPrice(t, r, b, "10", "2026-10-05T12:00:00Z", "")
+
+	prices, err := r.HistoricalPricesBySymbols(ctx, []int64{a, b, none}, mustParseTime(t, "2026-10-05T00:00:00Z"), mustParseTime(t, "2026-10-06T00:00:00Z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 2 || len(prices[a]) != 2 || len(prices[b]) != 1 {
+		t.Fatalf("got %v symbols, %v prices of A and %v of B, want 2, 2 and 1", len(prices), len(prices[a]), len(prices[b]))
+	}
+	if prices[a][0].Price.String() != "1" || prices[a][1].Price.String() != "2" || prices[b][0].Price.String() != "10" {
+		t.Errorf("got %+v", prices)
+	}
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	QuarantineStatusPending  = "pending"
+	QuarantineStatusApproved = "approved"
+	QuarantineStatusRejected = "rejected"
+)
+
+// QuarantinedPriceRow is a QuarantinedPrice alongside its symbol's ticker.
+type QuarantinedPriceRow struct {
+	QuarantinedPrice
+	Symbol string
+}
+
+// LatestPrice returns the symbol's most recent stored price.
+func (r *Repo) LatestPrice(ctx context.Context, symbolID int64) (Price, error) {
+	var p Price
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, symbol_id, price, currency, timestamp, source_id, scraped_at
+		 FROM prices
+		 WHERE symbol_id = ?
+		 ORDER BY timestamp DESC, id DESC
+		 LIMIT 1`, symbolID,
+	).Scan(&p.ID, &p.SymbolID, &p.Price, &p.Currency, &p.Timestamp, &p.SourceID, &p.ScrapedAt)
+	return p, err
+}
+
+// InsertQuarantinedPrice holds back a price. The same price quarantined again
+// by a later pass is ignored rather than queued twice.
+func (r *Repo) InsertQuarantinedPrice(ctx context.Context, q QuarantinedPrice) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO quarantined_prices (symbol_id, source_id, price, currency, timestamp, scraped_at, reason)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (symbol_id, source_id, currency, timestamp) DO NOTHING`,
+		q.SymbolID, q.SourceID, q.Price, q.Currency, q.Timestamp, q.ScrapedAt, q.Reason)
+	return err
+}
+
+func (r *Repo) QuarantinedPriceByID(ctx context.Context, id int64) (QuarantinedPriceRow, error) {
+	var q QuarantinedPriceRow
+	err := r.db.QueryRowContext(ctx,
+		`SELECT q.id, q.symbol_id, q.source_id, q.price, q.currency, q.timestamp, q.scraped_at,
+		        q.reason, q.status, q.reviewed_at, s.symbol
+		 FROM quarantined_prices q
+		 JOIN symbols s ON s.id = q.symbol_id
+		 WHERE q.id = ?`, id,
+	).Scan(&q.ID, &q.SymbolID, &q.SourceID, &q.Price, &q.Currency, &q.Timestamp, &q.ScrapedAt,
+		&q.Reason, &q.Status, &q.ReviewedAt, &q.Symbol)
+	return q, err
+}
+
+// QuarantinedPrices lists quarantined prices with the given status, newest
+// first. An empty status lists all of them.
+func (r *Repo) QuarantinedPrices(ctx context.Context, status string) ([]QuarantinedPriceRow, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT q.id, q.symbol_id, q.source_id, q.price, q.currency, q.timestamp, q.scraped_at,
+		        q.reason, q.status, q.reviewed_at, s.symbol
+		 FROM quarantined_prices q
+		 JOIN symbols s ON s.id = q.symbol_id
+		 WHERE ?1 = '' OR q.status = ?1
+		 ORDER BY q.id DESC`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var quarantined []QuarantinedPriceRow
+	for rows.Next() {
+		var q QuarantinedPriceRow
+		if err := rows.Scan(&q.ID, &q.SymbolID, &q.SourceID, &q.Price, &q.Currency, &q.Timestamp, &q.ScrapedAt,
+			&q.Reason, &q.Status, &q.ReviewedAt, &q.Symbol); err != nil {
+			return nil, fmt.Errorf("error scanning quarantined price: %w", err)
+		}
+		quarantined = append(quarantined, q)
+	}
+	return quarantined, rows.Err()
+}
+
+// PendingQuarantinedPrices returns up to limit of the symbol's pending
+// quarantined prices with a timestamp after after and before before, newest
+// first.
+func (r *Repo) PendingQuarantinedPrices(ctx context.Context, symbolID int64, after time.Time, before time.Time, limit int) ([]QuarantinedPrice, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, symbol_id, source_id, price, currency, timestamp, scraped_at, reason, status, reviewed_at
+		 FROM quarantined_prices
+		 WHERE symbol_id = ? AND status = ? AND timestamp > ? AND timestamp < ?
+		 ORDER BY timestamp DESC
+		 LIMIT ?`, symbolID, QuarantineStatusPending, after, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var quarantined []QuarantinedPrice
+	for rows.Next() {
+		var q QuarantinedPrice
+		if err := rows.Scan(&q.ID, &q.SymbolID, &q.SourceID, &q.Price, &q.Currency, &q.Timestamp, &q.ScrapedAt,
+			&q.Reason, &q.Status, &q.ReviewedAt); err != nil {
+			return nil, fmt.Errorf("error scanning quarantined price: %w", err)
+		}
+		quarantined = append(quarantined, q)
+	}
+	return quarantined, rows.Err()
+}
+
+// ReviewQuarantinedPrice moves a pending quarantined price to status. It
+// reports false when the price was not pending, so it is only reviewed once.
+func (r *Repo) ReviewQuarantinedPrice(ctx context.Context, id int64, status string, reviewedAt time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE quarantined_prices SET status = ?, reviewed_at = ? WHERE id = ? AND status = ?`,
+		status, reviewedAt, id, QuarantineStatusPending)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// deleteBatchSize bounds the ids deleted per statement, so a first run over
+// years of prices does not build one enormous json_each list.
+const deleteBatchSize = 5000
+
+// DownsampleResult is how many of a symbol's prices downsampling removed, or
+// would remove, at each step.
+type DownsampleResult struct {
+	HourlyRemoved int
+	DailyRemoved  int
+}
+
+// DownsamplePrices thins out the symbol's prices older than hourlyBefore to
+// the last instant of each UTC hour, and those older than dailyBefore to the
+// last instant of each day in the exchange's time zone. Every source's price
+// at that instant is kept, so HistoricalPrices still picks by priority. A
+// zero cutoff skips its step. With dryRun nothing is deleted.
+//
+// Bars are left as they are: they were aggregated from the full-resolution
+// prices when those were scraped, and are the accurate record of older ranges.
+// The cutoff is recorded on the symbol, so RefreshPriceBars does not later
+// rebuild them from the thinned prices. Both cutoffs are moved back to the
+// start of their week, so that no bar reaches across one: a bar that did would
+// be kept as it is while still open, and miss the prices stored after it.
+func (r *Repo) DownsamplePrices(ctx context.Context, symbolID int64, hourlyBefore time.Time, dailyBefore time.Time, dryRun bool) (DownsampleResult, error) {
+	var result DownsampleResult
+	if hourlyBefore.IsZero() && dailyBefore.IsZero() {
+		return result, nil
+	}
+	loc, err := r.symbolLocation(ctx, symbolID)
+	if err != nil {
+		return result, fmt.Errorf("error getting time zone of symbol %v: %w", symbolID, err)
+	}
+	if !hourlyBefore.IsZero() {
+		hourlyBefore = barStart(PriceBarInterval1w, hourlyBefore, loc)
+	}
+	if !dailyBefore.IsZero() {
+		dailyBefore = barStart(PriceBarInterval1w, dailyBefore, loc)
+	}
+	cutoff := maxTime(hourlyBefore, dailyBefore)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, currency, timestamp FROM prices
+		 WHERE symbol_id = ? AND timestamp < ?`, symbolID, cutoff)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	type bucket struct {
+		interval string
+		currency string
+		start    time.Time
+	}
+	type price struct {
+		id        int64
+		bucket    bucket
+		timestamp time.Time
+	}
+	var prices []price
+	last := map[bucket]time.Time{}
+	for rows.Next() {
+		var p price
+		var currency string
+		if err := rows.Scan(&p.id, &currency, &p.timestamp); err != nil {
+			return result, fmt.Errorf("error scanning price: %w", err)
+		}
+		interval := PriceBarInterval1h
+		if !dailyBefore.IsZero() && p.timestamp.Before(dailyBefore) {
+			interval = PriceBarInterval1d
+		}
+		p.bucket = bucket{interval, currency, barStart(interval, p.timestamp, loc)}
+		if p.timestamp.After(last[p.bucket]) {
+			last[p.bucket] = p.timestamp
+		}
+		prices = append(prices, p)
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	var removed []int64
+	for _, p := range prices {
+		if p.timestamp.Equal(last[p.bucket]) {
+			continue
+		}
+		removed = append(removed, p.id)
+		if p.bucket.interval == PriceBarInterval1d {
+			result.DailyRemoved++
+		} else {
+			result.HourlyRemoved++
+		}
+	}
+	if dryRun || len(removed) == 0 {
+		return result, nil
+	}
+
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+	for start := 0; start < len(removed); start += deleteBatchSize {
+		idList, err := jsonArray(removed[start:min(start+deleteBatchSize, len(removed))])
+		if err != nil {
+			return result, err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM prices WHERE id IN (SELECT value FROM json_each(?))`, idList); err != nil {
+			return result, fmt.Errorf("error deleting prices: %w", err)
+		}
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE symbols SET downsampled_before = ?
+		 WHERE id = ? AND (downsampled_before IS NULL OR downsampled_before < ?)`,
+		cutoff.UTC(), symbolID, cutoff.UTC())
+	if err != nil {
+		return result, fmt.Errorf("error recording downsampling cutoff: %w", err)
+	}
+	return result, tx.Commit()
+}
@@ -0,0 +1,249 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// storedPrices lists the symbol's prices as "timestamp source", oldest first.
+func storedPrices(t *testing.T, r *Repo, symbolID int64) []string {
+	t.Helper()
+	rows, err := r.db.QueryContext(context.Background(),
+		`SELECT timestamp, COALESCE(source_id, '') FROM prices WHERE symbol_id = ? ORDER BY timestamp, source_id`, symbolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var prices []string
+	for rows.Next() {
+		var ts time.Time
+		var source string
+		if err := rows.Scan(&ts, &source); err != nil {
+			t.Fatal(err)
+		}
+		prices = append(prices, strings.TrimSpace(ts.UTC().Format(time.RFC3339)+" "+source))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return prices
+}
+
+func TestDownsamplePrices(t *testing.T) {
+	type price struct{ timestamp, source string }
+	tests := []struct {
+		name         string
+		exchangeID   string
+		prices       []price
+		hourlyBefore string
+		dailyBefore  string
+		dryRun       bool
+		want         DownsampleResult
+		kept         []string
+	}{
+		{
+			name: "hourly keeps the last price of each hour",
+			prices: []price{
+				{"2026-10-05T10:05:00Z", ""}, {"2026-10-05T10:30:00Z", ""}, {"2026-10-05T10:55:00Z", ""},
+				{"2026-10-05T11:10:00Z", ""},
+			},
+			hourlyBefore: "2026-10-12T00:00:00Z",
+			want:         DownsampleResult{HourlyRemoved: 2},
+			kept:         []string{"2026-10-05T10:55:00Z", "2026-10-05T11:10:00Z"},
+		},
+		{
+			name: "prices after the cutoff are kept",
+			prices: []price{
+				{"2026-10-11T23:05:00Z", ""}, {"2026-10-11T23:30:00Z", ""}, {"2026-10-12T00:10:00Z", ""},
+			},
+			hourlyBefore: "2026-10-12T00:00:00Z",
+			want:         DownsampleResult{HourlyRemoved: 1},
+			kept:         []string{"2026-10-11T23:30:00Z", "2026-10-12T00:10:00Z"},
+		},
+		{
+			name: "cutoff moves back to the start of its week",
+			prices: []price{
+				{"2026-10-05T10:05:00Z", ""}, {"2026-10-05T10:30:00Z", ""},
+				{"2026-10-12T10:05:00Z", ""}, {"2026-10-12T10:30:00Z", ""},
+			},
+			hourlyBefore: "2026-10-14T10:45:00Z",
+			want:         DownsampleResult{HourlyRemoved: 1},
+			kept:         []string{"2026-10-05T10:30:00Z", "2026-10-12T10:05:00Z", "2026-10-12T10:30:00Z"},
+		},
+		{
+			name: "dry run deletes nothing",
+			prices: []price{
+				{"2026-10-05T10:05:00Z", ""}, {"2026-10-05T10:30:00Z", ""},
+			},
+			hourlyBefore: "2026-10-12T00:00:00Z",
+			dryRun:       true,
+			want:         DownsampleResult{HourlyRemoved: 1},
+			kept:         []string{"2026-10-05T10:05:00Z", "2026-10-05T10:30:00Z"},
+		},
+		{
+			name: "every source at the last instant is kept",
+			prices: []price{
+				{"2026-10-05T10:05:00Z", "a"}, {"2026-10-05T10:55:00Z", "a"}, {"2026-10-05T10:55:00Z", "b"},
+			},
+			hourlyBefore: "2026-10-12T00:00:00Z",
+			want:         DownsampleResult{HourlyRemoved: 1},
+			kept:         []string{"2026-10-05T10:55:00Z a", "2026-10-05T10:55:00Z b"},
+		},
+		{
+			name: "daily before the daily cutoff, hourly after it",
+			prices: []price{
+				{"2026-10-05T10:05:00Z", ""}, {"2026-10-05T11:30:00Z", ""},
+				{"2026-10-13T10:05:00Z", ""}, {"2026-10-13T10:30:00Z", ""}, {"2026-10-13T11:30:00Z", ""},
+				{"2026-10-20T10:05:00Z", ""}, {"2026-10-20T10:30:00Z", ""},
+			},
+			hourlyBefore: "2026-10-19T00:00:00Z",
+			dailyBefore:  "2026-10-12T00:00:00Z",
+			want:         DownsampleResult{HourlyRemoved: 1, DailyRemoved: 1},
+			kept: []string{
+				"2026-10-05T11:30:00Z",
+				"2026-10-13T10:30:00Z", "2026-10-13T11:30:00Z",
+				"2026-10-20T10:05:00Z", "2026-10-20T10:30:00Z",
+			},
+		},
+		{
+			// 22:30 UTC is already the next day in Berlin.
+			name:       "days are those of the exchange time zone",
+			exchangeID: "XETR",
+			prices: []price{
+				{"2026-10-05T20:00:00Z", ""}, {"2026-10-05T21:30:00Z", ""},
+				{"2026-10-05T22:30:00Z", ""}, {"2026-10-05T23:00:00Z", ""},
+			},
+			dailyBefore: "2026-10-12T00:00:00Z",
+			want:        DownsampleResult{DailyRemoved: 2},
+			kept:        []string{"2026-10-05T21:30:00Z", "2026-10-05T23:00:00Z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			id := insertTestSymbol(t, r, "A", tt.exchangeID)
+			for _, p := range tt.prices {
+				insertTestPrice(t, r, id, "1", p.timestamp, p.source)
+			}
+			var hourlyBefore, dailyBefore time.Time
+			if tt.hourlyBefore != "" {
+				hourlyBefore = mustParseTime(t, tt.hourlyBefore)
+			}
+			if tt.dailyBefore != "" {
+				dailyBefore = mustParseTime(t, tt.dailyBefore)
+			}
+			result, err := r.DownsamplePrices(context.Background(), id, hourlyBefore, dailyBefore, tt.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result != tt.want {
+				t.Errorf("got %+v, want %+v", result, tt.want)
+			}
+			kept := storedPrices(t, r, id)
+			if strings.Join(kept, ",") != strings.Join(tt.kept, ",") {
+				t.Errorf("kept %v, want %v", kept, tt.kept)
+			}
+		})
+	}
+}
+
+func TestDownsamplePricesKeepsBars(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	id := insertTestSymbol(t, r, "A", "")
+	for _, p := range []struct{ price, timestamp string }{
+		{"100", "2026-10-05T10:00:00Z"}, {"140", "2026-10-05T10:30:00Z"}, {"120", "2026-10-05T11:00:00Z"},
+		{"110", "2026-10-06T10:00:00Z"}, {"132", "2026-10-06T10:30:00Z"}, {"125", "2026-10-06T11:00:00Z"},
+	} {
+		insertTestPrice(t, r, id, p.price, p.timestamp, "")
+	}
+	if _, err := r.RebuildPriceBars(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.DownsamplePrices(ctx, id, time.Time{}, mustParseTime(t, "2026-10-12T00:00:00Z"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	// A late price inside the downsampled week, and one after the cutoff.
+	insertTestPrice(t, r, id, "90", "2026-10-07T10:00:00Z", "")
+	insertTestPrice(t, r, id, "150", "2026-10-12T10:00:00Z", "")
+	if err := r.RefreshPriceBars(ctx, id, mustParseTime(t, "2026-10-07T10:00:00Z"), mustParseTime(t, "2026-10-12T10:00:00Z")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RebuildPriceBars(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+
+	bars, err := r.PriceBars(ctx, id, PriceBarInterval1w, mustParseTime(t, "2026-10-01T00:00:00Z"), mustParseTime(t, "2026-10-31T00:00:00Z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 2 {
+		t.Fatalf("got %v weekly bars, want 2", len(bars))
+	}
+	if got := bars[0]; got.High.String() != "140" || got.Low.String() != "100" || got.Count != 6 {
+		t.Errorf("downsampled week was rebuilt: high %v low %v count %v", got.High, got.Low, got.Count)
+	}
+	if got := bars[1]; got.Open.String() != "150" || got.Count != 1 {
+		t.Errorf("week after the cutoff: open %v count %v, want 150 and 1", got.Open, got.Count)
+	}
+
+	days, err := r.PriceBars(ctx, id, PriceBarInterval1d, mustParseTime(t, "2026-10-05T00:00:00Z"), mustParseTime(t, "2026-10-07T00:00:00Z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 3 || days[0].High.String() != "140" || days[0].Count != 3 || days[2].Open.String() != "90" {
+		t.Errorf("got daily bars %+v", days)
+	}
+}
+
+// A price stored after retention ran mid-week still reaches the open week's
+// and day's bars.
+func TestDownsamplePricesLeavesOpenBars(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	id := insertTestSymbol(t, r, "A", "")
+	for _, p := range []struct{ price, timestamp string }{
+		{"10", "2026-10-12T09:00:00Z"}, {"11", "2026-10-12T09:30:00Z"}, {"12", "2026-10-14T09:00:00Z"},
+	} {
+		insertTestPrice(t, r, id, p.price, p.timestamp, "")
+	}
+	if _, err := r.RebuildPriceBars(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	// Wednesday noon is inside both the week and the day.
+	if _, err := r.DownsamplePrices(ctx, id, mustParseTime(t, "2026-10-14T12:00:00Z"), time.Time{}, false); err != nil {
+		t.Fatal(err)
+	}
+	insertTestPrice(t, r, id, "20", "2026-10-14T15:00:00Z", "")
+	if err := r.RefreshPriceBars(ctx, id, mustParseTime(t, "2026-10-14T15:00:00Z"), mustParseTime(t, "2026-10-14T15:00:00Z")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		interval string
+		start    string
+		want     string // open high low close count
+	}{
+		{PriceBarInterval1w, "2026-10-12T00:00:00Z", "10 20 10 20 4"},
+		{PriceBarInterval1d, "2026-10-14T00:00:00Z", "12 20 12 20 2"},
+	}
+	for _, tt := range tests {
+		start := mustParseTime(t, tt.start)
+		bars, err := r.PriceBars(ctx, id, tt.interval, start, start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bars) != 1 {
+			t.Fatalf("got %v %v bars, want 1", len(bars), tt.interval)
+		}
+		b := bars[0]
+		got := strings.Join([]string{b.Open.String(), b.High.String(), b.Low.String(), b.Close.String(), strconv.Itoa(b.Count)}, " ")
+		if got != tt.want {
+			t.Errorf("%v bar = %v, want %v", tt.interval, got, tt.want)
+		}
+	}
+}
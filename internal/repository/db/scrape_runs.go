@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	ScrapeRunStatusPending   = "pending"
+	ScrapeRunStatusRunning   = "running"
+	ScrapeRunStatusSucceeded = "succeeded"
+	// ScrapeRunStatusPartial means the pass finished, but some symbols failed.
+	ScrapeRunStatusPartial = "partial"
+	ScrapeRunStatusFailed  = "failed"
+	ScrapeRunStatusSkipped = "skipped"
+	// ScrapeRunStatusStale is only used for results: the source answered, but
+	// with a stale price that a fresher source superseded.
+	ScrapeRunStatusStale = "stale"
+	// ScrapeRunStatusQuarantined is only used for results: the source's price
+	// failed a sanity check and was held back for review.
+	ScrapeRunStatusQuarantined = "quarantined"
+)
+
+// ScrapeRunResultRow is a ScrapeRunResult alongside its symbol's ticker.
+type ScrapeRunResultRow struct {
+	ScrapeRunResult
+	Symbol string
+}
+
+func (r *Repo) InsertScrapeRun(ctx context.Context, trigger string, startedAt time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO scrape_runs (trigger, status, started_at) VALUES (?, ?, ?)`,
+		trigger, ScrapeRunStatusPending, startedAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *Repo) UpdateScrapeRunStatus(ctx context.Context, id int64, status string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE scrape_runs SET status = ? WHERE id = ?`, status, id)
+	return err
+}
+
+// FinishScrapeRun records the final status. runErr may be empty.
+func (r *Repo) FinishScrapeRun(ctx context.Context, id int64, status string, runErr string, finishedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE scrape_runs SET status = ?, error = ?, finished_at = ? WHERE id = ?`,
+		status, sql.NullString{String: runErr, Valid: runErr != ""}, finishedAt, id)
+	return err
+}
+
+// InsertScrapeRunResult records the outcome for one symbol-source, and counts a
+// successful one towards the run's prices_inserted.
+func (r *Repo) InsertScrapeRunResult(ctx context.Context, result ScrapeRunResult) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO scrape_run_results (run_id, symbol_id, source_id, status, error, price, currency, timestamp, scraped_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		result.RunID, result.SymbolID, result.SourceID, result.Status, result.Error,
+		result.Price, result.Currency, result.Timestamp, result.ScrapedAt)
+	if err != nil {
+		return err
+	}
+	if result.Status != ScrapeRunStatusSucceeded {
+		return nil
+	}
+	_, err = r.db.ExecContext(ctx,
+		`UPDATE scrape_runs SET prices_inserted = prices_inserted + 1 WHERE id = ?`, result.RunID)
+	return err
+}
+
+func (r *Repo) ScrapeRunByID(ctx context.Context, id int64) (ScrapeRun, error) {
+	var s ScrapeRun
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, trigger, status, started_at, finished_at, error, prices_inserted
+		 FROM scrape_runs
+		 WHERE id = ?`, id,
+	).Scan(&s.ID, &s.Trigger, &s.Status, &s.StartedAt, &s.FinishedAt, &s.Error, &s.PricesInserted)
+	return s, err
+}
+
+// ScrapeRuns returns the most recent runs, newest first.
+func (r *Repo) ScrapeRuns(ctx context.Context, limit int) ([]ScrapeRun, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, trigger, status, started_at, finished_at, error, prices_inserted
+		 FROM scrape_runs
+		 ORDER BY id DESC
+		 LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []ScrapeRun
+	for rows.Next() {
+		var s ScrapeRun
+		if err := rows.Scan(&s.ID, &s.Trigger, &s.Status, &s.StartedAt, &s.FinishedAt, &s.Error, &s.PricesInserted); err != nil {
+			return nil, fmt.Errorf("error scanning scrape run: %w", err)
+		}
+		runs = append(runs, s)
+	}
+	return runs, rows.Err()
+}
+
+func (r *Repo) ScrapeRunResults(ctx context.Context, runID int64) ([]ScrapeRunResultRow, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT rr.id, rr.run_id, rr.symbol_id, rr.source_id, rr.status, rr.error,
+		        rr.price, rr.currency, rr.timestamp, rr.scraped_at, s.symbol
+		 FROM scrape_run_results rr
+		 JOIN symbols s ON s.id = rr.symbol_id
+		 WHERE rr.run_id = ?
+		 ORDER BY rr.id ASC`, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []ScrapeRunResultRow
+	for rows.Next() {
+		var rr ScrapeRunResultRow
+		if err := rows.Scan(&rr.ID, &rr.RunID, &rr.SymbolID, &rr.SourceID, &rr.Status, &rr.Error,
+			&rr.Price, &rr.Currency, &rr.Timestamp, &rr.ScrapedAt, &rr.Symbol); err != nil {
+			return nil, fmt.Errorf("error scanning scrape run result: %w", err)
+		}
+		results = append(results, rr)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+func (r *Repo) ScrapingSources(ctx context.Context) ([]ScrapingSource, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, name, base_url, additional_info, max_concurrency, requests_per_second
+		 FROM scraping_sources
+		 ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []ScrapingSource
+	for rows.Next() {
+		var s ScrapingSource
+		if err := rows.Scan(&s.ID, &s.Name, &s.BaseUrl, &s.AdditionalInfo, &s.MaxConcurrency, &s.RequestsPerSecond); err != nil {
+			return nil, fmt.Errorf("error scanning scraping source: %w", err)
+		}
+		sources = append(sources, s)
+	}
+	return sources, rows.Err()
+}
+
+func (r *Repo) InsertScrapingSource(ctx context.Context, s ScrapingSource) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO scraping_sources (id, name, base_url, additional_info, max_concurrency, requests_per_second)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		s.ID, s.Name, s.BaseUrl, s.AdditionalInfo, s.MaxConcurrency, s.RequestsPerSecond)
+	return err
+}
+
+func (r *Repo) UpdateScrapingSource(ctx context.Context, s ScrapingSource) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE scraping_sources
+		 SET name = ?, base_url = ?, additional_info = ?, max_concurrency = ?, requests_per_second = ?
+		 WHERE id = ?`,
+		s.Name, s.BaseUrl, s.AdditionalInfo, s.MaxConcurrency, s.RequestsPerSecond, s.ID)
+	return err
+}
+
+// DeleteScrapingSource deletes the source and every symbol's use of it. Prices
+// it supplied keep their source_id as provenance. It reports false if there
+// was no such source.
+func (r *Repo) DeleteScrapingSource(ctx context.Context, id string) (bool, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM symbol_sources WHERE source_id = ?`, id); err != nil {
+		return false, fmt.Errorf("error deleting symbol sources: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM scraping_sources WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, tx.Commit()
+}
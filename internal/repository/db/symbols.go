@@ -2,52 +2,101 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 )
 
 func (r *Repo) SymbolByID(ctx context.Context, id int64) (Symbol, error) {
 	var s Symbol
 	err := r.db.QueryRowContext(ctx,
-		`SELECT id, symbol, name, isin FROM symbols WHERE id = ?`, id,
-	).Scan(&s.ID, &s.Symbol, &s.Name, &s.Isin)
+		`SELECT id, symbol, name, isin, active, exchange_id FROM symbols WHERE id = ?`, id,
+	).Scan(&s.ID, &s.Symbol, &s.Name, &s.Isin, &s.Active, &s.ExchangeID)
 	return s, err
 }
 
 func (r *Repo) SymbolByTicker(ctx context.Context, ticker string) (Symbol, error) {
 	var s Symbol
 	err := r.db.QueryRowContext(ctx,
-		`SELECT id, symbol, name, isin FROM symbols WHERE symbol = ?`, ticker,
-	).Scan(&s.ID, &s.Symbol, &s.Name, &s.Isin)
+		`SELECT id, symbol, name, isin, active, exchange_id FROM symbols WHERE symbol = ?`, ticker,
+	).Scan(&s.ID, &s.Symbol, &s.Name, &s.Isin, &s.Active, &s.ExchangeID)
 	return s, err
 }
 
+// SymbolByISIN returns the symbol with the ISIN. A security listed on several
+// exchanges can be tracked under several tickers that share an ISIN; the
+// first one added is returned.
+func (r *Repo) SymbolByISIN(ctx context.Context, isin string) (Symbol, error) {
+	var s Symbol
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, symbol, name, isin, active, exchange_id FROM symbols WHERE isin = ? ORDER BY id LIMIT 1`, isin,
+	).Scan(&s.ID, &s.Symbol, &s.Name, &s.Isin, &s.Active, &s.ExchangeID)
+	return s, err
+}
+
+// SymbolsByTickers returns the symbols with the given tickers, in no
+// particular order. Tickers without a symbol are left out.
+func (r *Repo) SymbolsByTickers(ctx context.Context, tickers []string) ([]Symbol, error) {
+	tickerList, err := jsonArray(tickers)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, symbol, name, isin, active, exchange_id FROM symbols WHERE symbol IN (SELECT value FROM json_each(?))`, tickerList)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var symbols []Symbol
+	for rows.Next() {
+		var s Symbol
+		if err := rows.Scan(&s.ID, &s.Symbol, &s.Name, &s.Isin, &s.Active, &s.ExchangeID); err != nil {
+			return nil, fmt.Errorf("error scanning symbol: %w", err)
+		}
+		symbols = append(symbols, s)
+	}
+	return symbols, rows.Err()
+}
+
 func (r *Repo) ScrapingSourceByID(ctx context.Context, id string) (ScrapingSource, error) {
 	var s ScrapingSource
 	err := r.db.QueryRowContext(ctx,
-		`SELECT id, name, base_url, additional_info FROM scraping_sources WHERE id = ?`, id,
-	).Scan(&s.ID, &s.Name, &s.BaseUrl, &s.AdditionalInfo)
+		`SELECT id, name, base_url, additional_info, max_concurrency, requests_per_second
+		 FROM scraping_sources
+		 WHERE id = ?`, id,
+	).Scan(&s.ID, &s.Name, &s.BaseUrl, &s.AdditionalInfo, &s.MaxConcurrency, &s.RequestsPerSecond)
 	return s, err
 }
 
 func (r *Repo) SymbolSource(ctx context.Context, symbolID int64, sourceID string) (SymbolSource, error) {
 	var s SymbolSource
 	err := r.db.QueryRowContext(ctx,
-		`SELECT id, symbol_id, source_id, scrape_url, active, last_scraped
+		`SELECT id, symbol_id, source_id, scrape_url, active, last_scraped, consecutive_failures, priority
 		 FROM symbol_sources
 		 WHERE symbol_id = ? AND source_id = ?`, symbolID, sourceID,
-	).Scan(&s.ID, &s.SymbolID, &s.SourceID, &s.ScrapeUrl, &s.Active, &s.LastScraped)
+	).Scan(&s.ID, &s.SymbolID, &s.SourceID, &s.ScrapeUrl, &s.Active, &s.LastScraped, &s.ConsecutiveFailures, &s.Priority)
 	return s, err
 }
 
-// SourcesNotScrapedRecently returns active symbol sources that have not been
-// scraped within the last 10 minutes.
+// SourcesNotScrapedRecently returns the active sources of every active symbol
+// that no source has scraped within the last 10 minutes, ordered by symbol and
+// then by priority, lowest (most preferred) first.
 func (r *Repo) SourcesNotScrapedRecently(ctx context.Context) ([]SymbolSource, error) {
 	rows, err := r.db.QueryContext(ctx,
-		`SELECT id, symbol_id, source_id, scrape_url, active, last_scraped
+		`SELECT id, symbol_id, source_id, scrape_url, active, last_scraped, consecutive_failures, priority
 		 FROM symbol_sources
 		 WHERE active = TRUE
-		   AND (last_scraped IS NULL OR DATETIME(last_scraped, '+10 minutes') <= DATETIME('now'))`)
+		   AND symbol_id IN (
+		       SELECT ss.symbol_id
+		       FROM symbol_sources ss
+		       JOIN symbols s ON s.id = ss.symbol_id
+		       WHERE ss.active = TRUE AND s.active = TRUE
+		       GROUP BY ss.symbol_id
+		       HAVING MAX(last_scraped) IS NULL
+		           OR DATETIME(MAX(last_scraped), '+10 minutes') <= DATETIME('now'))
+		 ORDER BY symbol_id, priority, id`)
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +105,7 @@ func (r *Repo) SourcesNotScrapedRecently(ctx context.Context) ([]SymbolSource, e
 	var sources []SymbolSource
 	for rows.Next() {
 		var s SymbolSource
-		if err := rows.Scan(&s.ID, &s.SymbolID, &s.SourceID, &s.ScrapeUrl, &s.Active, &s.LastScraped); err != nil {
+		if err := rows.Scan(&s.ID, &s.SymbolID, &s.SourceID, &s.ScrapeUrl, &s.Active, &s.LastScraped, &s.ConsecutiveFailures, &s.Priority); err != nil {
 			return nil, fmt.Errorf("error scanning symbol source: %w", err)
 		}
 		sources = append(sources, s)
@@ -64,9 +113,216 @@ func (r *Repo) SourcesNotScrapedRecently(ctx context.Context) ([]SymbolSource, e
 	return sources, rows.Err()
 }
 
+// UpdateLastScraped records a successful scrape, which also ends any run of
+// failures.
 func (r *Repo) UpdateLastScraped(ctx context.Context, symbolID int64, sourceID string, lastScraped time.Time) error {
 	_, err := r.db.ExecContext(ctx,
-		`UPDATE symbol_sources SET last_scraped = ? WHERE symbol_id = ? AND source_id = ?`,
+		`UPDATE symbol_sources SET last_scraped = ?, consecutive_failures = 0 WHERE symbol_id = ? AND source_id = ?`,
 		lastScraped, symbolID, sourceID)
 	return err
 }
+
+// RecordScrapeFailure counts a failed scrape, and deactivates the symbol source
+// once it has failed maxFailures times in a row. A maxFailures of zero never
+// deactivates. It reports whether this failure deactivated the source.
+func (r *Repo) RecordScrapeFailure(ctx context.Context, symbolID int64, sourceID string, maxFailures int) (bool, error) {
+	var failures int
+	var active sql.NullBool
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE symbol_sources
+		 SET consecutive_failures = consecutive_failures + 1,
+		     active = CASE WHEN ?1 > 0 AND consecutive_failures + 1 >= ?1 THEN FALSE ELSE active END
+		 WHERE symbol_id = ?2 AND source_id = ?3
+		 RETURNING consecutive_failures, active`,
+		maxFailures, symbolID, sourceID,
+	).Scan(&failures, &active)
+	if err != nil {
+		return false, err
+	}
+	return maxFailures > 0 && failures == maxFailures && !active.Bool, nil
+}
+
+// SymbolSummary is a symbol alongside when any of its sources last scraped it.
+type SymbolSummary struct {
+	Symbol
+	LastScraped sql.NullTime
+}
+
+// latestScrapedSource selects the source of symbol s that scraped it last. It
+// is joined rather than taking MAX(last_scraped), because an aggregate loses
+// the column's DATETIME type and the driver would not parse it as a time.
+const latestScrapedSource = `SELECT id FROM symbol_sources WHERE symbol_id = s.id ORDER BY last_scraped DESC LIMIT 1`
+
+// Symbols returns every symbol, active or not, ordered by ticker.
+func (r *Repo) Symbols(ctx context.Context) ([]SymbolSummary, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT s.id, s.symbol, s.name, s.isin, s.active, s.exchange_id, ls.last_scraped
+		 FROM symbols s
+		 LEFT JOIN symbol_sources ls ON ls.id = (`+latestScrapedSource+`)
+		 ORDER BY s.symbol`)
+	if err != nil {
+		return nil, err
+	}
+	return scanSymbolSummaries(rows)
+}
+
+// SearchSymbols returns up to limit symbols whose ticker, name or ISIN has
+// words starting with each word of query, best match first. An exact ticker
+// match always comes first.
+func (r *Repo) SearchSymbols(ctx context.Context, query string, limit int) ([]SymbolSummary, error) {
+	match := ftsPrefixQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT s.id, s.symbol, s.name, s.isin, s.active, s.exchange_id, ls.last_scraped
+		 FROM symbols_fts
+		 JOIN symbols s ON s.id = symbols_fts.rowid
+		 LEFT JOIN symbol_sources ls ON ls.id = (`+latestScrapedSource+`)
+		 WHERE symbols_fts MATCH ?
+		 ORDER BY s.symbol = UPPER(?) DESC, symbols_fts.rank
+		 LIMIT ?`, match, strings.TrimSpace(query), limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanSymbolSummaries(rows)
+}
+
+func scanSymbolSummaries(rows *sql.Rows) ([]SymbolSummary, error) {
+	defer rows.Close()
+	var symbols []SymbolSummary
+	for rows.Next() {
+		var s SymbolSummary
+		if err := rows.Scan(&s.ID, &s.Symbol.Symbol, &s.Name, &s.Isin, &s.Active, &s.ExchangeID, &s.LastScraped); err != nil {
+			return nil, fmt.Errorf("error scanning symbol: %w", err)
+		}
+		symbols = append(symbols, s)
+	}
+	return symbols, rows.Err()
+}
+
+// ftsPrefixQuery turns free text into an FTS5 query matching rows that have a
+// word starting with each of its words. Each word is quoted, so none of it is
+// read as FTS5 syntax.
+func ftsPrefixQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if word != "" {
+			terms = append(terms, `"`+word+`"*`)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// InsertSymbol inserts s and returns its ID. s.ID is ignored.
+func (r *Repo) InsertSymbol(ctx context.Context, s Symbol) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO symbols (symbol, name, isin, active, exchange_id) VALUES (?, ?, ?, ?, ?) RETURNING id`,
+		s.Symbol, s.Name, s.Isin, s.Active, s.ExchangeID,
+	).Scan(&id)
+	return id, err
+}
+
+func (r *Repo) UpdateSymbol(ctx context.Context, s Symbol) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE symbols SET symbol = ?, name = ?, isin = ?, active = ?, exchange_id = ? WHERE id = ?`,
+		s.Symbol, s.Name, s.Isin, s.Active, s.ExchangeID, s.ID)
+	return err
+}
+
+// DeleteSymbol deletes the symbol along with its prices, sources and scrape
+// history. Foreign keys are not enforced, so the ON DELETE CASCADE clauses of
+// the schema never fire and the dependent rows are deleted here. It reports
+// false if there was no such symbol.
+func (r *Repo) DeleteSymbol(ctx context.Context, id int64) (bool, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"prices", "price_bars", "symbol_sources", "scrape_run_results", "quarantined_prices"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE symbol_id = ?`, id); err != nil {
+			return false, fmt.Errorf("error deleting %v: %w", table, err)
+		}
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM symbols WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, tx.Commit()
+}
+
+func (r *Repo) SymbolSourceByID(ctx context.Context, id int64) (SymbolSource, error) {
+	var s SymbolSource
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, symbol_id, source_id, scrape_url, active, last_scraped, consecutive_failures, priority
+		 FROM symbol_sources
+		 WHERE id = ?`, id,
+	).Scan(&s.ID, &s.SymbolID, &s.SourceID, &s.ScrapeUrl, &s.Active, &s.LastScraped, &s.ConsecutiveFailures, &s.Priority)
+	return s, err
+}
+
+// SymbolSourcesBySymbol returns every source of the symbol, active or not, in
+// priority order.
+func (r *Repo) SymbolSourcesBySymbol(ctx context.Context, symbolID int64) ([]SymbolSource, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, symbol_id, source_id, scrape_url, active, last_scraped, consecutive_failures, priority
+		 FROM symbol_sources
+		 WHERE symbol_id = ?
+		 ORDER BY priority, id`, symbolID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []SymbolSource
+	for rows.Next() {
+		var s SymbolSource
+		if err := rows.Scan(&s.ID, &s.SymbolID, &s.SourceID, &s.ScrapeUrl, &s.Active, &s.LastScraped, &s.ConsecutiveFailures, &s.Priority); err != nil {
+			return nil, fmt.Errorf("error scanning symbol source: %w", err)
+		}
+		sources = append(sources, s)
+	}
+	return sources, rows.Err()
+}
+
+// InsertSymbolSource inserts s and returns its ID. s.ID, s.LastScraped and
+// s.ConsecutiveFailures are ignored.
+func (r *Repo) InsertSymbolSource(ctx context.Context, s SymbolSource) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO symbol_sources (symbol_id, source_id, scrape_url, active, priority)
+		 VALUES (?, ?, ?, ?, ?)
+		 RETURNING id`,
+		s.SymbolID, s.SourceID, s.ScrapeUrl, s.Active, s.Priority,
+	).Scan(&id)
+	return id, err
+}
+
+// UpdateSymbolSource updates the scrape URL, active flag, priority and failure
+// count of s. Its symbol and source are fixed.
+func (r *Repo) UpdateSymbolSource(ctx context.Context, s SymbolSource) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE symbol_sources
+		 SET scrape_url = ?, active = ?, priority = ?, consecutive_failures = ?
+		 WHERE id = ?`,
+		s.ScrapeUrl, s.Active, s.Priority, s.ConsecutiveFailures, s.ID)
+	return err
+}
+
+// DeleteSymbolSource reports false if there was no such symbol source.
+func (r *Repo) DeleteSymbolSource(ctx context.Context, id int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM symbol_sources WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
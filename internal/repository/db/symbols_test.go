@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestFtsPrefixQuery(t *testing.T) {
+	tests := []struct{ text, want string }{
+		{"", ""},
+		{"  ", ""},
+		{"msci", `"msci"*`},
+		{" msci  world ", `"msci"* "world"*`},
+		{`"AND OR`, `"AND"* "OR"*`},
+		{`" NEAR(`, `"NEAR("*`},
+	}
+	for _, tt := range tests {
+		if got := ftsPrefixQuery(tt.text); got != tt.want {
+			t.Errorf("ftsPrefixQuery(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+// The triggers keep symbols_fts in step with symbols.
+func TestSearchSymbolsFollowsChanges(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	search := func(query string) string {
+		t.Helper()
+		symbols, err := r.SearchSymbols(ctx, query, 10)
+		if err != nil {
+			t.Fatalf("search %q: %v", query, err)
+		}
+		var tickers []string
+		for _, s := range symbols {
+			tickers = append(tickers, s.Symbol.Symbol)
+		}
+		return strings.Join(tickers, ",")
+	}
+
+	insert := func(ticker, name, isin string) int64 {
+		t.Helper()
+		id, err := r.InsertSymbol(ctx, Symbol{Symbol: ticker, Name: sql.NullString{String: name, Valid: true}, Isin: isin, Active: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	// IWDA's name mentions EUNL, so it matches a search for it, but the exact
+	// ticker comes first.
+	insert("IWDA", "iShares Core MSCI World, also listed as EUNL", "IE00B4L5Y983")
+	eunl := insert("EUNL", "iShares Core MSCI World", "IE00B4L5Y983")
+	insert("SAP", "SAP SE", "DE0007164600")
+
+	tests := []struct{ query, want string }{
+		{"eunl", "EUNL,IWDA"},
+		{"DE000716", "SAP"},
+		{"apple", ""},
+	}
+	for _, tt := range tests {
+		if got := search(tt.query); got != tt.want {
+			t.Errorf("search %q = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	symbol, err := r.SymbolByID(ctx, eunl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbol.Symbol = "EUNX"
+	symbol.Name = sql.NullString{String: "Renamed Fund", Valid: true}
+	if err := r.UpdateSymbol(ctx, symbol); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("renamed"); got != "EUNX" {
+		t.Errorf("search for the new name = %q, want EUNX", got)
+	}
+	if got := search("eunl"); got != "IWDA" {
+		t.Errorf("search for the old ticker = %q, want only IWDA", got)
+	}
+
+	if _, err := r.DeleteSymbol(ctx, eunl); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("renamed"); got != "" {
+		t.Errorf("search after delete = %q, want nothing", got)
+	}
+}
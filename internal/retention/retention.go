@@ -0,0 +1,71 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+)
+
+type RetentionService struct {
+	appContext *core.AppContext
+}
+
+func NewRetentionService(appContext *core.AppContext) core.RetentionService {
+	return &RetentionService{appContext: appContext}
+}
+
+// ApplyRetention implements core.RetentionService. Symbols are downsampled one
+// at a time, each in its own transaction, so a failure part way keeps what was
+// already done and a rerun picks up the rest.
+func (s *RetentionService) ApplyRetention(ctx context.Context, dryRun bool) (core.RetentionReport, error) {
+	cfg := s.appContext.Config
+	if cfg.RetentionHourlyAfter <= 0 && cfg.RetentionDailyAfter <= 0 {
+		return core.RetentionReport{}, core.ErrRetentionDisabled
+	}
+	repo, err := db.OpenRepo(cfg)
+	if err != nil {
+		return core.RetentionReport{}, fmt.Errorf("error opening db: %w", err)
+	}
+
+	now := time.Now().UTC()
+	report := core.RetentionReport{DryRun: dryRun, StartedAt: now, Symbols: []core.RetentionSymbolReport{}}
+	var hourlyBefore, dailyBefore time.Time
+	if cfg.RetentionHourlyAfter > 0 {
+		hourlyBefore = now.Add(-cfg.RetentionHourlyAfter)
+		report.HourlyBefore = &hourlyBefore
+	}
+	if cfg.RetentionDailyAfter > 0 {
+		dailyBefore = now.Add(-cfg.RetentionDailyAfter)
+		report.DailyBefore = &dailyBefore
+	}
+
+	symbols, err := repo.Symbols(ctx)
+	if err != nil {
+		return report, fmt.Errorf("error getting symbols: %w", err)
+	}
+	for _, symbol := range symbols {
+		result, err := repo.DownsamplePrices(ctx, symbol.ID, hourlyBefore, dailyBefore, dryRun)
+		if err != nil {
+			return report, fmt.Errorf("error downsampling prices of %v: %w", symbol.Symbol.Symbol, err)
+		}
+		if result.HourlyRemoved+result.DailyRemoved == 0 {
+			continue
+		}
+		report.Symbols = append(report.Symbols, core.RetentionSymbolReport{
+			Symbol:        symbol.Symbol.Symbol,
+			HourlyRemoved: result.HourlyRemoved,
+			DailyRemoved:  result.DailyRemoved,
+		})
+		report.RowsRemoved += result.HourlyRemoved + result.DailyRemoved
+		if !dryRun {
+			s.appContext.Deps.QuoteService.ClearCache(ctx, symbol.Symbol.Symbol)
+		}
+	}
+	report.FinishedAt = time.Now().UTC()
+	slog.Info("applied price retention", "dry_run", dryRun, "rows_removed", report.RowsRemoved, "symbols", len(report.Symbols))
+	return report, nil
+}
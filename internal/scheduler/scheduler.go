@@ -0,0 +1,158 @@
+// Package scheduler runs scrape passes in-process on a fixed interval, so
+// scraping no longer depends on something external calling POST /api/job. It
+// also runs the daily price retention job, when retention is configured, and
+// loads the exchange rate history once at startup.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+)
+
+// passTimeout bounds a single scrape pass, matching fire-and-forget jobs.
+const passTimeout = 5 * time.Minute
+
+// retentionInterval is how often old prices are downsampled. Once a day is
+// plenty: the windows are measured in days.
+const retentionInterval = 24 * time.Hour
+
+type Scheduler struct {
+	appContext *core.AppContext
+	interval   time.Duration
+	windows    []Window
+	location   *time.Location
+	wg         sync.WaitGroup
+}
+
+func NewScheduler(appContext *core.AppContext) (*Scheduler, error) {
+	windows, err := ParseWindows(appContext.Config.ScrapeWindows)
+	if err != nil {
+		return nil, err
+	}
+	location := appContext.Config.ScrapeTimezone
+	if location == nil {
+		location = time.UTC
+	}
+	return &Scheduler{
+		appContext: appContext,
+		interval:   appContext.Config.ScrapeInterval,
+		windows:    windows,
+		location:   location,
+	}, nil
+}
+
+// Start scrapes once immediately and then every interval until ctx is
+// canceled, and likewise applies retention every retentionInterval. Either
+// does nothing when not configured. Passes run one at a time on a single
+// goroutine, so a slow pass delays the next tick rather than overlapping it.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.loadExchangeRateHistory(ctx)
+	s.startRetention(ctx)
+	if s.interval <= 0 {
+		slog.Info("scrape scheduler disabled")
+		return
+	}
+	slog.Info("starting scrape scheduler", "interval", s.interval.String(), "windows", len(s.windows))
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.runPass(ctx)
+			select {
+			case <-ctx.Done():
+				slog.Info("scrape scheduler stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// loadExchangeRateHistory fetches the exchange rate history in the
+// background, so startup and requests do not wait for the download.
+func (s *Scheduler) loadExchangeRateHistory(ctx context.Context) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.appContext.Deps.ExchangeRateService.LoadHistory(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("loading exchange rate history failed", "error", err)
+		}
+	}()
+}
+
+func (s *Scheduler) startRetention(ctx context.Context) {
+	cfg := s.appContext.Config
+	if cfg.RetentionHourlyAfter <= 0 && cfg.RetentionDailyAfter <= 0 {
+		slog.Info("price retention disabled")
+		return
+	}
+	slog.Info("starting price retention", "hourly_after", cfg.RetentionHourlyAfter.String(), "daily_after", cfg.RetentionDailyAfter.String())
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(retentionInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.appContext.Deps.RetentionService.ApplyRetention(ctx, false); err != nil && ctx.Err() == nil {
+				slog.Error("applying price retention failed", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Wait blocks until the scheduler goroutines have returned, or ctx expires.
+func (s *Scheduler) Wait(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("scrape scheduler did not stop in time")
+	}
+}
+
+func (s *Scheduler) runPass(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+	if !s.inWindow(time.Now()) {
+		slog.Debug("outside scrape windows, skipping scheduled scrape")
+		return
+	}
+	runID, err := s.appContext.Deps.ScraperService.NewRun(ctx, core.ScrapeTriggerScheduler)
+	if err != nil {
+		slog.Error("creating scheduled scrape run failed", "error", err)
+		return
+	}
+	passCtx, cancel := context.WithTimeout(ctx, passTimeout)
+	defer cancel()
+	// ScrapeSymbols logs and records its own failures.
+	_ = s.appContext.Deps.ScraperService.ScrapeSymbols(passCtx, runID)
+}
+
+func (s *Scheduler) inWindow(t time.Time) bool {
+	if len(s.windows) == 0 {
+		return true
+	}
+	local := t.In(s.location)
+	for _, w := range s.windows {
+		if w.Contains(local) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a weekly recurring span, e.g. Mon-Fri 08:00-22:00, in which
+// scheduled scraping is allowed.
+type Window struct {
+	Days  map[time.Weekday]bool
+	Start time.Duration // since midnight
+	End   time.Duration // since midnight, exclusive
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseWindows parses ";"-separated windows such as
+// "Mon-Fri 08:00-22:00; Sat 10:00-12:00". Days are a range or a
+// comma-separated list; a window cannot span midnight, but may end at 24:00.
+func ParseWindows(spec string) ([]Window, error) {
+	var windows []Window
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		window, err := parseWindow(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scrape window %q: %w", part, err)
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+func parseWindow(spec string) (Window, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 2 {
+		return Window{}, fmt.Errorf("want \"<days> <HH:MM>-<HH:MM>\"")
+	}
+	days, err := parseDays(fields[0])
+	if err != nil {
+		return Window{}, err
+	}
+	startStr, endStr, ok := strings.Cut(fields[1], "-")
+	if !ok {
+		return Window{}, fmt.Errorf("time range %q is missing a \"-\"", fields[1])
+	}
+	start, err := parseClock(startStr)
+	if err != nil {
+		return Window{}, err
+	}
+	end, err := parseClock(endStr)
+	if err != nil {
+		return Window{}, err
+	}
+	if end <= start {
+		return Window{}, fmt.Errorf("end %v is not after start %v", endStr, startStr)
+	}
+	return Window{Days: days, Start: start, End: end}, nil
+}
+
+func parseDays(spec string) (map[time.Weekday]bool, error) {
+	days := map[time.Weekday]bool{}
+	for _, part := range strings.Split(spec, ",") {
+		fromStr, toStr, isRange := strings.Cut(part, "-")
+		from, ok := weekdays[strings.ToLower(fromStr)]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", fromStr)
+		}
+		to := from
+		if isRange {
+			to, ok = weekdays[strings.ToLower(toStr)]
+			if !ok {
+				return nil, fmt.Errorf("unknown weekday %q", toStr)
+			}
+		}
+		// Ranges may wrap the week, e.g. Sun-Thu or Fri-Mon.
+		for d := from; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	// Only meaningful as an end, and a start there is rejected as not
+	// before its end.
+	if s == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains reports whether t, already in the windows' time zone, falls inside w.
+func (w Window) Contains(t time.Time) bool {
+	if !w.Days[t.Weekday()] {
+		return false
+	}
+	sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	return sinceMidnight >= w.Start && sinceMidnight < w.End
+}
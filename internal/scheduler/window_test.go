@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindows(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []string // days, start and end of each window
+		wantErr bool
+	}{
+		{spec: "", want: nil},
+		{spec: " ; ", want: nil},
+		{spec: "Mon-Fri 08:00-22:00", want: []string{"12345 8h0m0s-22h0m0s"}},
+		{spec: "mon-fri 08:00-22:00; Sat 10:00-12:30", want: []string{"12345 8h0m0s-22h0m0s", "6 10h0m0s-12h30m0s"}},
+		{spec: "Mon,Wed,Fri 00:00-24:00", want: []string{"135 0s-24h0m0s"}},
+		{spec: "Fri-Mon 18:00-24:00", want: []string{"0156 18h0m0s-24h0m0s"}},
+		{spec: "Sun-Sun 09:00-10:00", want: []string{"0 9h0m0s-10h0m0s"}},
+		{spec: "Mon-Fri", wantErr: true},
+		{spec: "Mon-Fri 08:00 22:00", wantErr: true},
+		{spec: "Mon-Fri 0800-2200", wantErr: true},
+		{spec: "Mon-Fry 08:00-22:00", wantErr: true},
+		{spec: "Mon 22:00-08:00", wantErr: true},
+		{spec: "Mon 08:00-08:00", wantErr: true},
+		{spec: "Mon 24:00-24:00", wantErr: true},
+		{spec: "Mon 08:00-24:30", wantErr: true},
+		{spec: "Mon 08:00-25:00", wantErr: true},
+		{spec: "Mon 08:00-22:00; Tue", wantErr: true},
+	}
+	for _, tt := range tests {
+		windows, err := ParseWindows(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseWindows(%q) succeeded, want an error", tt.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseWindows(%q): %v", tt.spec, err)
+			continue
+		}
+		var got []string
+		for _, w := range windows {
+			days := ""
+			for d := time.Sunday; d <= time.Saturday; d++ {
+				if w.Days[d] {
+					days += string(rune('0' + d))
+				}
+			}
+			got = append(got, days+" "+w.Start.String()+"-"+w.End.String())
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseWindows(%q) = %v, want %v", tt.spec, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ParseWindows(%q) = %v, want %v", tt.spec, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestInWindow(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	windows, err := ParseWindows("Mon-Fri 08:00-22:00; Sat 18:00-24:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Scheduler{windows: windows, location: berlin}
+
+	// 2026-07-06 is a Monday; Berlin is UTC+2 in summer.
+	tests := []struct {
+		utc  string
+		want bool
+	}{
+		{"2026-07-06T05:59:59Z", false},
+		{"2026-07-06T06:00:00Z", true},
+		{"2026-07-06T19:59:59Z", true},
+		{"2026-07-06T20:00:00Z", false},
+		{"2026-07-10T21:00:00Z", false}, // Friday 23:00
+		{"2026-07-11T15:59:59Z", false}, // Saturday 17:59:59
+		{"2026-07-11T16:00:00Z", true},
+		{"2026-07-11T21:59:59Z", true},  // Saturday 23:59:59
+		{"2026-07-11T22:00:00Z", false}, // Sunday 00:00
+	}
+	for _, tt := range tests {
+		at, err := time.Parse(time.RFC3339, tt.utc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.inWindow(at); got != tt.want {
+			t.Errorf("inWindow(%v) = %v, want %v", tt.utc, got, tt.want)
+		}
+	}
+
+	if !(&Scheduler{location: berlin}).inWindow(time.Now()) {
+		t.Error("no windows should allow any time")
+	}
+}
@@ -0,0 +1,149 @@
+package scrapers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+)
+
+// Backfill implements core.ScraperService. Backfilled prices skip the
+// deviation and currency checks, which compare against the latest price and
+// say nothing about old ones; prices that cannot be right are still skipped.
+// Rerunning a backfill stores nothing twice.
+func (s *ScraperService) Backfill(ctx context.Context, symbolID int64, input core.BackfillInput) (core.BackfillResult, error) {
+	start, end, err := parseBackfillRange(input, time.Now().UTC())
+	if err != nil {
+		return core.BackfillResult{}, err
+	}
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return core.BackfillResult{}, fmt.Errorf("error opening db: %w", err)
+	}
+	symbol, err := repo.SymbolByID(ctx, symbolID)
+	if err != nil {
+		return core.BackfillResult{}, fmt.Errorf("error getting symbol %v: %w", symbolID, err)
+	}
+	scraper, err := s.historicalScraper(ctx, repo, symbol, input.SourceID)
+	if err != nil {
+		return core.BackfillResult{}, err
+	}
+
+	results, err := scraper.ScrapeHistory(ctx, symbol, start, end)
+	if err != nil {
+		return core.BackfillResult{}, fmt.Errorf("error scraping history of symbol %v from %v: %w", symbol.Symbol, scraper.SourceIdentifier(), err)
+	}
+
+	result := core.BackfillResult{
+		Symbol:   symbol.Symbol,
+		SourceID: scraper.SourceIdentifier(),
+		Start:    start,
+		End:      end,
+		Fetched:  len(results),
+	}
+	scrapedAt := time.Now().UTC()
+	var prices []db.Price
+	var first, last time.Time
+	for _, r := range results {
+		if !r.Price.IsPositive() || r.Currency == "" || r.Timestamp.Before(start) || r.Timestamp.After(end) {
+			result.Skipped++
+			continue
+		}
+		prices = append(prices, db.Price{
+			SymbolID:  symbol.ID,
+			Price:     r.Price,
+			Currency:  r.Currency,
+			Timestamp: r.Timestamp,
+			SourceID:  sql.NullString{String: result.SourceID, Valid: true},
+			ScrapedAt: sql.NullTime{Time: scrapedAt, Valid: true},
+		})
+		if first.IsZero() || r.Timestamp.Before(first) {
+			first = r.Timestamp
+		}
+		if r.Timestamp.After(last) {
+			last = r.Timestamp
+		}
+	}
+	if len(prices) == 0 {
+		return result, nil
+	}
+	if result.Inserted, err = repo.InsertHistoricalPrices(ctx, prices); err != nil {
+		return result, fmt.Errorf("error inserting history of symbol %v: %w", symbol.Symbol, err)
+	}
+	if err := repo.RefreshPriceBars(ctx, symbol.ID, first, last); err != nil {
+		slog.Warn("refreshing price bars after backfill failed", "symbol", symbol.Symbol, "error", err)
+	}
+	s.appContext.Deps.QuoteService.ClearCache(ctx, symbol.Symbol)
+	slog.Info("backfilled symbol", "symbol", symbol.Symbol, "source", result.SourceID, "fetched", result.Fetched, "inserted", result.Inserted, "skipped", result.Skipped)
+	return result, nil
+}
+
+// historicalScraper is the source named by sourceID, or else the first of the
+// symbol's sources, in priority order, that serves past prices.
+func (s *ScraperService) historicalScraper(ctx context.Context, repo *db.Repo, symbol db.Symbol, sourceID string) (HistoricalScraper, error) {
+	if sourceID != "" {
+		scraper, err := MakeScraper(sourceID, s.appContext)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown source %v", core.ErrInvalidInput, sourceID)
+		}
+		historical, ok := scraper.(HistoricalScraper)
+		if !ok {
+			return nil, fmt.Errorf("%w: source %v does not serve past prices", core.ErrInvalidInput, sourceID)
+		}
+		return historical, nil
+	}
+	symbolSources, err := repo.SymbolSourcesBySymbol(ctx, symbol.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting sources of symbol %v: %w", symbol.Symbol, err)
+	}
+	for _, ss := range symbolSources {
+		scraper, err := MakeScraper(ss.SourceID, s.appContext)
+		if err != nil {
+			continue
+		}
+		if historical, ok := scraper.(HistoricalScraper); ok {
+			return historical, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no source of symbol %v serves past prices", core.ErrInvalidInput, symbol.Symbol)
+}
+
+// parseBackfillRange parses the range of input. A date as End includes the
+// whole of that day.
+func parseBackfillRange(input core.BackfillInput, now time.Time) (time.Time, time.Time, error) {
+	if input.Start == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: start is required", core.ErrInvalidInput)
+	}
+	start, _, err := parseBackfillTime(input.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid start: %w", core.ErrInvalidInput, err)
+	}
+	end := now
+	if input.End != "" {
+		var isDate bool
+		end, isDate, err = parseBackfillTime(input.End)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid end: %w", core.ErrInvalidInput, err)
+		}
+		if isDate {
+			end = end.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: end must be after start", core.ErrInvalidInput)
+	}
+	return start, end, nil
+}
+
+func parseBackfillTime(value string) (time.Time, bool, error) {
+	if len(value) == len(time.DateOnly) {
+		t, err := time.Parse(time.DateOnly, value)
+		return t, true, err
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
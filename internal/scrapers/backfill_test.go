@@ -0,0 +1,70 @@
+package scrapers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+)
+
+func TestParseBackfillRange(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		input     core.BackfillInput
+		wantStart string
+		wantEnd   string
+		wantErr   bool
+	}{
+		{
+			name:      "end defaults to now",
+			input:     core.BackfillInput{Start: "2024-03-01"},
+			wantStart: "2024-03-01T00:00:00Z",
+			wantEnd:   "2024-03-15T12:00:00Z",
+		},
+		{
+			name:      "end date includes the whole day",
+			input:     core.BackfillInput{Start: "2024-03-01", End: "2024-03-10"},
+			wantStart: "2024-03-01T00:00:00Z",
+			wantEnd:   "2024-03-10T23:59:59.999999999Z",
+		},
+		{
+			name:      "start and end on the same date",
+			input:     core.BackfillInput{Start: "2024-03-10", End: "2024-03-10"},
+			wantStart: "2024-03-10T00:00:00Z",
+			wantEnd:   "2024-03-10T23:59:59.999999999Z",
+		},
+		{
+			name:      "end timestamp is kept as is",
+			input:     core.BackfillInput{Start: "2024-03-01T08:00:00+01:00", End: "2024-03-10T17:30:00Z"},
+			wantStart: "2024-03-01T07:00:00Z",
+			wantEnd:   "2024-03-10T17:30:00Z",
+		},
+		{name: "missing start", input: core.BackfillInput{End: "2024-03-10"}, wantErr: true},
+		{name: "invalid start", input: core.BackfillInput{Start: "01/03/2024"}, wantErr: true},
+		{name: "invalid end", input: core.BackfillInput{Start: "2024-03-01", End: "2024-13-01"}, wantErr: true},
+		{name: "end before start", input: core.BackfillInput{Start: "2024-03-10", End: "2024-03-01"}, wantErr: true},
+		{name: "end equal to start", input: core.BackfillInput{Start: "2024-03-10T00:00:00Z", End: "2024-03-10T00:00:00Z"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := parseBackfillRange(tt.input, now)
+			if tt.wantErr {
+				if !errors.Is(err, core.ErrInvalidInput) {
+					t.Fatalf("got %v, want ErrInvalidInput", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := start.UTC().Format(time.RFC3339Nano); got != tt.wantStart {
+				t.Errorf("start = %v, want %v", got, tt.wantStart)
+			}
+			if got := end.UTC().Format(time.RFC3339Nano); got != tt.wantEnd {
+				t.Errorf("end = %v, want %v", got, tt.wantEnd)
+			}
+		})
+	}
+}
@@ -2,10 +2,7 @@ package scrapers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"time"
 
 	"github.com/bjarke-xyz/stonks/internal/core"
@@ -38,22 +35,10 @@ func (b *BorseFrankfurtScraper) Scrape(ctx context.Context, symbol db.Symbol) (S
 		return ScrapeResult{}, fmt.Errorf("error getting symbol source: %w", err)
 	}
 
-	resp, err := http.Get(symbolSource.ScrapeUrl)
-	if err != nil {
-		return ScrapeResult{}, fmt.Errorf("error HTTP GETting %v: %w", symbolSource.ScrapeUrl, err)
-	}
-
-	defer resp.Body.Close()
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return ScrapeResult{}, fmt.Errorf("error reading response body: %w", err)
-	}
-	bodyStr := string(bodyBytes)
-
 	parsedResponse := borseFrankfurtResponse{}
-	err = json.Unmarshal(bodyBytes, &parsedResponse)
+	err = defaultScrapeClient.getJSON(ctx, symbolSource.ScrapeUrl, nil, &parsedResponse)
 	if err != nil {
-		return ScrapeResult{}, fmt.Errorf("error parsing response body (%v): %w", bodyStr, err)
+		return ScrapeResult{}, err
 	}
 
 	return ScrapeResult{
@@ -0,0 +1,194 @@
+package scrapers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// TransientError is a failure worth retrying later: a timeout, a dropped
+// connection, a 429 or a 5xx.
+type TransientError struct {
+	Err error
+}
+
+func (e *TransientError) Error() string { return "transient: " + e.Err.Error() }
+func (e *TransientError) Unwrap() error { return e.Err }
+
+// PermanentError is a failure that retrying will not fix: a 4xx, or a body that
+// is not the JSON the scraper expects.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return "permanent: " + e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// StatusError is a non-2xx response. Body holds the start of the response, to
+// show what the source said.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("GET %v: status %v: %v", e.URL, e.StatusCode, e.Body)
+}
+
+// IsTransient reports whether err, or anything it wraps, is a TransientError.
+func IsTransient(err error) bool {
+	var transient *TransientError
+	return errors.As(err, &transient)
+}
+
+// maxErrorBody is how much of a failed response ends up in a StatusError.
+const maxErrorBody = 512
+
+// scrapeClient is the HTTP client every scraper shares. It bounds each
+// attempt with a timeout, and retries transient failures with exponential
+// backoff and full jitter, honoring Retry-After.
+type scrapeClient struct {
+	client      *http.Client
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+var defaultScrapeClient = &scrapeClient{
+	client:      &http.Client{Timeout: 15 * time.Second},
+	maxAttempts: 4,
+	baseDelay:   500 * time.Millisecond,
+	maxDelay:    30 * time.Second,
+}
+
+// getJSON GETs url and decodes the JSON body into target. Every error it
+// returns is a *TransientError or a *PermanentError.
+func (c *scrapeClient) getJSON(ctx context.Context, url string, header http.Header, target any) error {
+	var err error
+	for attempt := 0; attempt < c.maxAttempts; attempt++ {
+		var retryAfter time.Duration
+		retryAfter, err = c.tryGetJSON(ctx, url, header, target)
+		if err == nil || !IsTransient(err) || attempt == c.maxAttempts-1 {
+			break
+		}
+
+		delay := c.backoff(attempt)
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+		if delay > c.maxDelay {
+			// The source asked for a longer pause than a pass should sit
+			// through; leave it to the next pass.
+			break
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return &TransientError{Err: ctx.Err()}
+		}
+	}
+	return err
+}
+
+// tryGetJSON makes a single attempt. On a 429 or 503 it also returns the
+// Retry-After delay, if the source sent one.
+func (c *scrapeClient) tryGetJSON(ctx context.Context, url string, header http.Header, target any) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, &PermanentError{Err: fmt.Errorf("error creating request: %w", err)}
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, classifyRequestError(fmt.Errorf("error HTTP GETting %v: %w", url, err))
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, &TransientError{Err: fmt.Errorf("error reading response body: %w", err)}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		statusErr := &StatusError{URL: url, StatusCode: resp.StatusCode, Body: truncate(string(bodyBytes), maxErrorBody)}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return parseRetryAfter(resp.Header.Get("Retry-After")), &TransientError{Err: statusErr}
+		}
+		return 0, &PermanentError{Err: statusErr}
+	}
+
+	// An error page served with a 200 would otherwise surface as a confusing
+	// JSON syntax error.
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/html" {
+		return 0, &PermanentError{Err: fmt.Errorf("GET %v: got HTML instead of JSON: %v", url, truncate(string(bodyBytes), maxErrorBody))}
+	}
+	if err := json.Unmarshal(bodyBytes, target); err != nil {
+		return 0, &PermanentError{Err: fmt.Errorf("error parsing response body (%v): %w", truncate(string(bodyBytes), maxErrorBody), err)}
+	}
+	return 0, nil
+}
+
+// backoff is full jitter: a random delay up to baseDelay * 2^attempt, capped
+// at maxDelay.
+func (c *scrapeClient) backoff(attempt int) time.Duration {
+	ceiling := min(c.maxDelay, c.baseDelay<<attempt)
+	return rand.N(ceiling + 1)
+}
+
+// classifyRequestError treats timeouts, connection and DNS failures and a
+// dropped connection as transient, as well as a canceled context, since the
+// next pass may well succeed. Anything else, like a malformed URL, is permanent.
+func classifyRequestError(err error) error {
+	var netErr net.Error
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout(),
+		errors.As(err, &opErr),
+		errors.As(err, &dnsErr),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, context.Canceled):
+		return &TransientError{Err: err}
+	default:
+		return &PermanentError{Err: err}
+	}
+}
+
+// parseRetryAfter reads either form of Retry-After: delay-seconds or an
+// HTTP-date. It returns zero when the header is absent or unparseable.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(0, time.Until(at))
+	}
+	return 0
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "…"
+}
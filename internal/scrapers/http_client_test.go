@@ -0,0 +1,81 @@
+package scrapers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestScrapeClient() *scrapeClient {
+	return &scrapeClient{
+		client:      &http.Client{Timeout: time.Second},
+		maxAttempts: 3,
+		baseDelay:   time.Millisecond,
+		maxDelay:    50 * time.Millisecond,
+	}
+}
+
+func TestGetJSONRetriesTransientStatus(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"price": 1}`))
+	}))
+	defer srv.Close()
+
+	var target struct{ Price int }
+	if err := newTestScrapeClient().getJSON(context.Background(), srv.URL, nil, &target); err != nil {
+		t.Fatalf("getJSON: %v", err)
+	}
+	if target.Price != 1 || calls.Load() != 2 {
+		t.Errorf("price = %d after %d calls, want 1 after 2", target.Price, calls.Load())
+	}
+}
+
+func TestGetJSONErrorKinds(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		contentType   string
+		body          string
+		wantTransient bool
+		wantCalls     int32
+	}{
+		{"5xx exhausts retries", http.StatusBadGateway, "text/plain", "bad gateway", true, 3},
+		{"4xx is not retried", http.StatusNotFound, "application/json", `{}`, false, 1},
+		{"html error page", http.StatusOK, "text/html; charset=utf-8", "<html>maintenance</html>", false, 1},
+		{"malformed json", http.StatusOK, "application/json", `{"price":`, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			var target struct{ Price int }
+			err := newTestScrapeClient().getJSON(context.Background(), srv.URL, nil, &target)
+			if err == nil {
+				t.Fatal("getJSON succeeded, want an error")
+			}
+			if IsTransient(err) != tt.wantTransient {
+				t.Errorf("IsTransient(%v) = %v, want %v", err, IsTransient(err), tt.wantTransient)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package scrapers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+	"github.com/samber/lo"
+)
+
+// quarantinePrice holds back a scraped price that failed validation. Like the
+// other bookkeeping writes it is only logged on failure.
+func (s *ScraperService) quarantinePrice(ctx context.Context, repo *db.Repo, symbol db.Symbol, sourceID string, scrapeResult ScrapeResult, reason string) {
+	slog.Warn("quarantining scraped price", "symbol", symbol.Symbol, "source", sourceID, "price", scrapeResult.Price, "currency", scrapeResult.Currency, "reason", reason)
+	err := repo.InsertQuarantinedPrice(context.WithoutCancel(ctx), db.QuarantinedPrice{
+		SymbolID:  symbol.ID,
+		SourceID:  sourceID,
+		Price:     scrapeResult.Price,
+		Currency:  scrapeResult.Currency,
+		Timestamp: scrapeResult.Timestamp,
+		ScrapedAt: time.Now().UTC(),
+		Reason:    reason,
+	})
+	if err != nil {
+		slog.Warn("quarantining price failed", "symbol", symbol.Symbol, "source", sourceID, "error", err)
+	}
+}
+
+// QuarantinedPrices implements core.ScraperService.
+func (s *ScraperService) QuarantinedPrices(ctx context.Context, status string) ([]core.QuarantinedPrice, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return nil, fmt.Errorf("error opening db: %w", err)
+	}
+	dbQuarantined, err := repo.QuarantinedPrices(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("error getting quarantined prices: %w", err)
+	}
+	return lo.Map(dbQuarantined, func(q db.QuarantinedPriceRow, _ int) core.QuarantinedPrice { return toCoreQuarantinedPrice(q) }), nil
+}
+
+// ApproveQuarantinedPrice implements core.ScraperService. The price is stored
+// as if the source had just been scraped and marked approved in one
+// transaction, so a concurrent approval or rejection either wins or leaves it
+// unstored.
+func (s *ScraperService) ApproveQuarantinedPrice(ctx context.Context, id int64) (core.QuarantinedPrice, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return core.QuarantinedPrice{}, fmt.Errorf("error opening db: %w", err)
+	}
+	q, err := repo.QuarantinedPriceByID(ctx, id)
+	if err != nil {
+		return core.QuarantinedPrice{}, fmt.Errorf("error getting quarantined price %v: %w", id, err)
+	}
+	if q.Status != db.QuarantineStatusPending {
+		return core.QuarantinedPrice{}, core.ErrNotPending
+	}
+	var approved core.QuarantinedPrice
+	err = repo.InTx(ctx, func(tx *db.Repo) error {
+		err := tx.InsertPrice(ctx, db.Price{
+			SymbolID:  q.SymbolID,
+			Price:     q.Price,
+			Currency:  q.Currency,
+			Timestamp: q.Timestamp,
+			SourceID:  sql.NullString{String: q.SourceID, Valid: true},
+			ScrapedAt: sql.NullTime{Time: q.ScrapedAt, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("error inserting quarantined price %v: %w", id, err)
+		}
+		approved, err = s.review(ctx, tx, q, db.QuarantineStatusApproved)
+		return err
+	})
+	if err != nil {
+		return core.QuarantinedPrice{}, err
+	}
+	refreshPriceBars(ctx, repo, q.SymbolID, q.Timestamp)
+	s.appContext.Deps.QuoteService.ClearCache(ctx, q.Symbol)
+	return approved, nil
+}
+
+// RejectQuarantinedPrice implements core.ScraperService.
+func (s *ScraperService) RejectQuarantinedPrice(ctx context.Context, id int64) (core.QuarantinedPrice, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return core.QuarantinedPrice{}, fmt.Errorf("error opening db: %w", err)
+	}
+	q, err := repo.QuarantinedPriceByID(ctx, id)
+	if err != nil {
+		return core.QuarantinedPrice{}, fmt.Errorf("error getting quarantined price %v: %w", id, err)
+	}
+	return s.review(ctx, repo, q, db.QuarantineStatusRejected)
+}
+
+func (s *ScraperService) review(ctx context.Context, repo *db.Repo, q db.QuarantinedPriceRow, status string) (core.QuarantinedPrice, error) {
+	reviewedAt := time.Now().UTC()
+	ok, err := repo.ReviewQuarantinedPrice(ctx, q.ID, status, reviewedAt)
+	if err != nil {
+		return core.QuarantinedPrice{}, fmt.Errorf("error reviewing quarantined price %v: %w", q.ID, err)
+	}
+	if !ok {
+		return core.QuarantinedPrice{}, core.ErrNotPending
+	}
+	q.Status = status
+	q.ReviewedAt = sql.NullTime{Time: reviewedAt, Valid: true}
+	return toCoreQuarantinedPrice(q), nil
+}
+
+func toCoreQuarantinedPrice(q db.QuarantinedPriceRow) core.QuarantinedPrice {
+	quarantined := core.QuarantinedPrice{
+		ID:        q.ID,
+		Symbol:    q.Symbol,
+		SourceID:  q.SourceID,
+		Price:     q.Price,
+		Currency:  q.Currency,
+		Timestamp: q.Timestamp,
+		ScrapedAt: q.ScrapedAt,
+		Reason:    q.Reason,
+		Status:    q.Status,
+	}
+	if q.ReviewedAt.Valid {
+		quarantined.ReviewedAt = &q.ReviewedAt.Time
+	}
+	return quarantined
+}
@@ -0,0 +1,65 @@
+package scrapers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+	"github.com/shopspring/decimal"
+)
+
+func TestReviewQuarantinedPrice(t *testing.T) {
+	ctx := context.Background()
+	s, repo := newTestScraperService(t)
+	symbol := insertTestSymbol(t, repo, "A")
+	timestamp := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	for i, price := range []int64{200, 300} {
+		err := repo.InsertQuarantinedPrice(ctx, db.QuarantinedPrice{
+			SymbolID:  symbol.ID,
+			SourceID:  ScrapingSourceIdentifierYFINANCEAPI,
+			Price:     decimal.NewFromInt(price),
+			Currency:  "EUR",
+			Timestamp: timestamp.Add(time.Duration(i) * time.Hour),
+			ScrapedAt: timestamp,
+			Reason:    "test",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	pending, err := s.QuarantinedPrices(ctx, db.QuarantineStatusPending)
+	if err != nil || len(pending) != 2 {
+		t.Fatalf("got %v pending prices (%v), want 2", len(pending), err)
+	}
+	// Newest first.
+	rejectID, approveID := pending[0].ID, pending[1].ID
+
+	approved, err := s.ApproveQuarantinedPrice(ctx, approveID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approved.Status != db.QuarantineStatusApproved || approved.ReviewedAt == nil {
+		t.Errorf("approved price has status %v, reviewed at %v", approved.Status, approved.ReviewedAt)
+	}
+	if _, err := s.ApproveQuarantinedPrice(ctx, approveID); !errors.Is(err, core.ErrNotPending) {
+		t.Errorf("second approval: got %v, want ErrNotPending", err)
+	}
+
+	if _, err := s.RejectQuarantinedPrice(ctx, rejectID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ApproveQuarantinedPrice(ctx, rejectID); !errors.Is(err, core.ErrNotPending) {
+		t.Errorf("approving a rejected price: got %v, want ErrNotPending", err)
+	}
+
+	last, err := repo.LatestPrice(ctx, symbol.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Price.String() != "200" || !last.Timestamp.Equal(timestamp) || last.SourceID.String != ScrapingSourceIdentifierYFINANCEAPI {
+		t.Errorf("latest stored price is %v at %v from %v, want only the approved one", last.Price, last.Timestamp, last.SourceID.String)
+	}
+}
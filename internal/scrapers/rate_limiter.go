@@ -0,0 +1,54 @@
+package scrapers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces calls to Wait at least interval apart, across all the
+// goroutines sharing it.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter allows requestsPerSecond calls per second. Zero or less never
+// waits.
+func newRateLimiter(requestsPerSecond float64) *rateLimiter {
+	l := &rateLimiter{}
+	if requestsPerSecond > 0 {
+		l.interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return l
+}
+
+// Wait blocks until the caller's slot comes up, or returns ctx's error if ctx
+// ends first.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -21,6 +21,15 @@ type Scraper interface {
 	SourceIdentifier() string
 }
 
+// HistoricalScraper is a Scraper whose source also serves past prices, which
+// lets a newly added symbol be backfilled.
+type HistoricalScraper interface {
+	Scraper
+	// ScrapeHistory returns the symbol's prices from start through end, in
+	// whatever resolution the source keeps.
+	ScrapeHistory(ctx context.Context, symbol db.Symbol, start time.Time, end time.Time) ([]ScrapeResult, error)
+}
+
 const (
 	ScrapingSourceIdentifierBORSFRA     = "BORSFRA"
 	ScrapingSourceIdentifierYFINANCEAPI = "YFINANCEAPI"
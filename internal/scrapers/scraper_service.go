@@ -2,96 +2,514 @@ package scrapers
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/bjarke-xyz/stonks/internal/core"
 	"github.com/bjarke-xyz/stonks/internal/repository/db"
 	"github.com/samber/lo"
+	"github.com/shopspring/decimal"
 )
 
 type ScraperService struct {
 	appContext *core.AppContext
+	// running is held for the duration of a pass. The scheduler and
+	// POST /api/job can both trigger one, and two passes would scrape the same
+	// due sources twice.
+	running sync.Mutex
 }
 
 func NewScraperService(appContext *core.AppContext) core.ScraperService {
 	return &ScraperService{appContext: appContext}
 }
 
-func (s *ScraperService) ScrapeSymbols(ctx context.Context) {
-	slog.Info("scraping symbols")
-	err := s.internalScrapeSymbols(ctx)
+// NewRun implements core.ScraperService.
+func (s *ScraperService) NewRun(ctx context.Context, trigger string) (int64, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return 0, fmt.Errorf("error opening db: %w", err)
+	}
+	runID, err := repo.InsertScrapeRun(ctx, trigger, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error inserting scrape run: %w", err)
+	}
+	return runID, nil
+}
+
+// ScrapeSymbols implements core.ScraperService.
+func (s *ScraperService) ScrapeSymbols(ctx context.Context, runID int64) error {
+	repo, err := db.OpenRepo(s.appContext.Config)
 	if err != nil {
-		slog.Error("scraping symbols failed", "error", err)
+		return fmt.Errorf("error opening db: %w", err)
 	}
+	return s.runPass(ctx, repo, runID, false, func() ([]db.SymbolSource, error) {
+		scrapeSources, err := repo.SourcesNotScrapedRecently(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting sources not scraped recently: %w", err)
+		}
+		return scrapeSources, nil
+	})
 }
 
-func (s *ScraperService) internalScrapeSymbols(ctx context.Context) error {
+// ScrapeSymbol implements core.ScraperService.
+func (s *ScraperService) ScrapeSymbol(ctx context.Context, runID int64, symbolID int64, force bool) error {
 	repo, err := db.OpenRepo(s.appContext.Config)
 	if err != nil {
-		return fmt.Errorf("error opening db")
+		return fmt.Errorf("error opening db: %w", err)
+	}
+	symbol, err := repo.SymbolByID(ctx, symbolID)
+	if err != nil {
+		err = fmt.Errorf("error getting symbol %v: %w", symbolID, err)
+		s.finishRun(context.WithoutCancel(ctx), repo, runID, db.ScrapeRunStatusFailed, err)
+		return err
+	}
+	if !force && s.marketClosed(symbol, time.Now()) {
+		err = fmt.Errorf("%w for %v on %v", core.ErrMarketClosed, symbol.Symbol, symbol.ExchangeID.String)
+		s.finishRun(context.WithoutCancel(ctx), repo, runID, db.ScrapeRunStatusSkipped, err)
+		return err
+	}
+	return s.runPass(ctx, repo, runID, force, func() ([]db.SymbolSource, error) {
+		symbolSources, err := repo.SymbolSourcesBySymbol(ctx, symbol.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting sources of %v: %w", symbol.Symbol, err)
+		}
+		return lo.Filter(symbolSources, func(ss db.SymbolSource, _ int) bool { return ss.Active.Bool }), nil
+	})
+}
+
+// runPass runs a pass over the symbol sources candidates returns, and records
+// how it went on the run. With force, symbols are scraped even while their
+// market is closed.
+func (s *ScraperService) runPass(ctx context.Context, repo *db.Repo, runID int64, force bool, candidates func() ([]db.SymbolSource, error)) error {
+	// Bookkeeping must land even when the pass itself was canceled, or the run
+	// would be left "running" forever.
+	bookkeepingCtx := context.WithoutCancel(ctx)
+
+	if !s.running.TryLock() {
+		slog.Info("scrape already running, skipping", "run_id", runID)
+		s.finishRun(bookkeepingCtx, repo, runID, db.ScrapeRunStatusSkipped, core.ErrScrapeInProgress)
+		return core.ErrScrapeInProgress
 	}
+	defer s.running.Unlock()
 
-	scrapeSources, err := repo.SourcesNotScrapedRecently(ctx)
+	slog.Info("scraping symbols", "run_id", runID)
+	if err := repo.UpdateScrapeRunStatus(ctx, runID, db.ScrapeRunStatusRunning); err != nil {
+		slog.Warn("updating scrape run status failed", "run_id", runID, "error", err)
+	}
+	report, err := s.internalScrapeSymbols(ctx, repo, runID, force, candidates)
 	if err != nil {
-		return fmt.Errorf("error getting sources not scraped recently: %w", err)
+		slog.Error("scraping symbols failed", "run_id", runID, "error", err)
+		s.finishRun(bookkeepingCtx, repo, runID, db.ScrapeRunStatusFailed, err)
+		return err
 	}
+	if report.failed > 0 {
+		failedErr := fmt.Errorf("%v of %v symbols failed", report.failed, report.attempted)
+		slog.Warn("scraping symbols partially failed", "run_id", runID, "failed", report.failed, "attempted", report.attempted)
+		s.finishRun(bookkeepingCtx, repo, runID, db.ScrapeRunStatusPartial, failedErr)
+		return nil
+	}
+	s.finishRun(bookkeepingCtx, repo, runID, db.ScrapeRunStatusSucceeded, nil)
+	return nil
+}
 
-	slog.Info("found scrape sources not scraped recently", "count", len(scrapeSources))
+func (s *ScraperService) finishRun(ctx context.Context, repo *db.Repo, runID int64, status string, runErr error) {
+	errText := ""
+	if runErr != nil {
+		errText = runErr.Error()
+	}
+	if err := repo.FinishScrapeRun(ctx, runID, status, errText, time.Now().UTC()); err != nil {
+		slog.Warn("finishing scrape run failed", "run_id", runID, "error", err)
+	}
+}
 
-	groupedScrapeSources := lo.GroupBy(scrapeSources, func(ss db.SymbolSource) string {
-		return ss.SourceID
+// Run implements core.ScraperService.
+func (s *ScraperService) Run(ctx context.Context, runID int64) (core.ScrapeRun, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return core.ScrapeRun{}, fmt.Errorf("error opening db: %w", err)
+	}
+	dbRun, err := repo.ScrapeRunByID(ctx, runID)
+	if err != nil {
+		return core.ScrapeRun{}, fmt.Errorf("error getting scrape run %v: %w", runID, err)
+	}
+	dbResults, err := repo.ScrapeRunResults(ctx, runID)
+	if err != nil {
+		return core.ScrapeRun{}, fmt.Errorf("error getting results of scrape run %v: %w", runID, err)
+	}
+	run := toCoreScrapeRun(dbRun)
+	run.Results = lo.Map(dbResults, func(rr db.ScrapeRunResultRow, _ int) core.ScrapeRunResult {
+		return toCoreScrapeRunResult(rr)
 	})
+	return run, nil
+}
 
-	for sourceIdentifier, scrapeSources := range groupedScrapeSources {
-		symbolIds := lo.Map(scrapeSources, func(ss db.SymbolSource, _ int) int64 { return ss.SymbolID })
-		err := s.scrapeSymbolsForSourceIdentifier(ctx, repo, sourceIdentifier, symbolIds)
-		if err != nil {
-			return fmt.Errorf("error scraping symbols for source identifier %v: %w", sourceIdentifier, err)
-		}
+// Runs implements core.ScraperService.
+func (s *ScraperService) Runs(ctx context.Context, limit int) ([]core.ScrapeRun, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return nil, fmt.Errorf("error opening db: %w", err)
+	}
+	dbRuns, err := repo.ScrapeRuns(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting scrape runs: %w", err)
+	}
+	return lo.Map(dbRuns, func(r db.ScrapeRun, _ int) core.ScrapeRun { return toCoreScrapeRun(r) }), nil
+}
+
+// scrapeReport tallies a pass by symbol. The per-source detail is in
+// scrape_run_results.
+type scrapeReport struct {
+	mu        sync.Mutex
+	attempted int
+	failed    int
+}
+
+func (r *scrapeReport) add(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempted++
+	if err != nil {
+		r.failed++
+	}
+}
+
+// sourceGate enforces a source's limits across every symbol in a pass: at most
+// cap(slots) requests in flight, started no faster than limiter allows.
+type sourceGate struct {
+	slots   chan struct{}
+	limiter *rateLimiter
+}
+
+func (g *sourceGate) acquire(ctx context.Context) error {
+	select {
+	case g.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if err := g.limiter.Wait(ctx); err != nil {
+		g.release()
+		return err
 	}
 	return nil
 }
 
-func (s *ScraperService) scrapeSymbolsForSourceIdentifier(ctx context.Context, repo *db.Repo, sourceIdentifier string, symbolIds []int64) error {
-	scraper, err := MakeScraper(sourceIdentifier, s.appContext)
+func (g *sourceGate) release() {
+	<-g.slots
+}
+
+// passSource is everything a pass needs to scrape from one source.
+type passSource struct {
+	scraper Scraper
+	makeErr error
+	gate    *sourceGate
+}
+
+// internalScrapeSymbols scrapes the symbols of the sources candidates returns,
+// ordered by symbol and priority, many at once. Each symbol goes through its
+// sources in priority order until one yields a fresh price. A failing symbol
+// is recorded and skipped rather than aborting the pass; only a failure to
+// find the sources, or ctx ending, stops it early.
+func (s *ScraperService) internalScrapeSymbols(ctx context.Context, repo *db.Repo, runID int64, force bool, candidates func() ([]db.SymbolSource, error)) (*scrapeReport, error) {
+	report := &scrapeReport{}
+	scrapeSources, err := candidates()
 	if err != nil {
-		return fmt.Errorf("error making scraping: %w", err)
+		return report, err
 	}
 
-	for _, symbolId := range symbolIds {
-		err = s.scrapeAndStoreSymbol(ctx, repo, scraper, symbolId)
-		if err != nil {
-			return fmt.Errorf("error scraping and storing symbol: %w", err)
+	// GroupBy keeps the query's priority order within each symbol.
+	symbolSources := lo.GroupBy(scrapeSources, func(ss db.SymbolSource) int64 { return ss.SymbolID })
+	slog.Info("found symbols not scraped recently", "count", len(symbolSources), "sources", len(scrapeSources))
+
+	sources := map[string]*passSource{}
+	workers := 0
+	for _, sourceIdentifier := range lo.Uniq(lo.Map(scrapeSources, func(ss db.SymbolSource, _ int) string { return ss.SourceID })) {
+		scraper, makeErr := MakeScraper(sourceIdentifier, s.appContext)
+		if makeErr != nil {
+			makeErr = fmt.Errorf("error making scraper: %w", makeErr)
 		}
+		concurrency, requestsPerSecond := s.sourceLimits(ctx, repo, sourceIdentifier)
+		sources[sourceIdentifier] = &passSource{
+			scraper: scraper,
+			makeErr: makeErr,
+			gate:    &sourceGate{slots: make(chan struct{}, concurrency), limiter: newRateLimiter(requestsPerSecond)},
+		}
+		workers += concurrency
 	}
-	return nil
+
+	queue := make(chan []db.SymbolSource)
+	var wg sync.WaitGroup
+	for range min(workers, len(symbolSources)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for candidates := range queue {
+				s.scrapeSymbol(ctx, repo, runID, candidates, sources, force, report)
+			}
+		}()
+	}
+
+feed:
+	for _, symbolId := range slices.Sorted(maps.Keys(symbolSources)) {
+		select {
+		case queue <- symbolSources[symbolId]:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+	return report, ctx.Err()
+}
+
+// sourceLimits reads how hard a source may be hit. A source without a
+// scraping_sources row gets one worker and no rate limit.
+func (s *ScraperService) sourceLimits(ctx context.Context, repo *db.Repo, sourceIdentifier string) (int, float64) {
+	scrapingSource, err := repo.ScrapingSourceByID(ctx, sourceIdentifier)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("getting scraping source limits failed", "source", sourceIdentifier, "error", err)
+		}
+		return 1, 0
+	}
+	return max(1, scrapingSource.MaxConcurrency), scrapingSource.RequestsPerSecond.Float64
 }
 
-func (s *ScraperService) scrapeAndStoreSymbol(ctx context.Context, repo *db.Repo, scraper Scraper, symbolId int64) error {
+// marketClosed reports whether symbol's exchange has been closed for longer
+// than ScrapeAfterClose, the time its sources get to publish the closing
+// price. Symbols without an exchange calendar are always scraped.
+func (s *ScraperService) marketClosed(symbol db.Symbol, now time.Time) bool {
+	calendar := s.appContext.Deps.CalendarService
+	return calendar.MarketStatus(symbol.ExchangeID.String, now).Closed() &&
+		calendar.MarketStatus(symbol.ExchangeID.String, now.Add(-s.appContext.Config.ScrapeAfterClose)).Closed()
+}
+
+// attempt is one source's answer for a symbol.
+type attempt struct {
+	sourceID string
+	result   ScrapeResult
+	err      error
+	// quarantine is why a price that looked wrong was held back, if it was.
+	quarantine string
+}
+
+// scrapeSymbol tries candidates, a symbol's sources in priority order, and
+// stores the first fresh price. If every source that answers is stale, the
+// newest of their prices is stored instead. Prices that fail validation are
+// never stored: invalid ones count as failures, and suspicious ones are
+// quarantined for review; a symbol whose every price was quarantined counts as
+// failed in the report. Unless force is set, a symbol whose market is closed is
+// skipped. Once ctx ends it gives up without
+// recording anything: every remaining scrape would fail, and counting those
+// against the sources would deactivate healthy ones.
+func (s *ScraperService) scrapeSymbol(ctx context.Context, repo *db.Repo, runID int64, candidates []db.SymbolSource, sources map[string]*passSource, force bool, report *scrapeReport) {
+	symbolId := candidates[0].SymbolID
 	symbol, err := repo.SymbolByID(ctx, symbolId)
 	if err != nil {
-		return fmt.Errorf("error getting symbol for id %v: %w", symbolId, err)
+		if ctx.Err() == nil {
+			slog.Warn("getting symbol failed", "run_id", runID, "symbol_id", symbolId, "error", err)
+			report.add(err)
+		}
+		return
+	}
+	if !force && s.marketClosed(symbol, time.Now()) {
+		slog.Debug("market closed, skipping symbol", "run_id", runID, "symbol", symbol.Symbol, "exchange", symbol.ExchangeID.String)
+		return
+	}
+
+	var attempts []attempt
+	chosen := -1
+	for _, candidate := range candidates {
+		a := attempt{sourceID: candidate.SourceID}
+		a.result, a.err = s.scrapeFromSource(ctx, symbol, sources[candidate.SourceID])
+		if a.err != nil && ctx.Err() != nil {
+			return
+		}
+		if a.err == nil {
+			a.quarantine, a.err = s.validate(ctx, repo, symbol, a.result)
+		}
+		attempts = append(attempts, a)
+		if a.err != nil {
+			continue
+		}
+		if a.quarantine != "" {
+			s.quarantinePrice(ctx, repo, symbol, a.sourceID, a.result, a.quarantine)
+			continue
+		}
+		if chosen == -1 || a.result.Timestamp.After(attempts[chosen].result.Timestamp) {
+			chosen = len(attempts) - 1
+		}
+		if !s.isStale(a.result) {
+			break
+		}
+		slog.Info("scraped price is stale, trying next source", "symbol", symbol.Symbol, "source", a.sourceID, "timestamp", a.result.Timestamp)
+	}
+
+	var storeErr error
+	if chosen != -1 {
+		storeErr = s.storePrice(ctx, repo, symbol, attempts[chosen].sourceID, attempts[chosen].result)
 	}
 
-	scrapeResult, err := scraper.Scrape(ctx, symbol)
+	var symbolErr error
+	for i, a := range attempts {
+		runResult := db.ScrapeRunResult{RunID: runID, SymbolID: symbol.ID, SourceID: a.sourceID}
+		status := db.ScrapeRunStatusFailed
+		err := a.err
+		if err == nil {
+			runResult.Price = decimal.NewNullDecimal(a.result.Price)
+			runResult.Currency = sql.NullString{String: a.result.Currency, Valid: true}
+			runResult.Timestamp = sql.NullTime{Time: a.result.Timestamp, Valid: true}
+			status = db.ScrapeRunStatusStale
+			if a.quarantine != "" {
+				status = db.ScrapeRunStatusQuarantined
+				runResult.Error = sql.NullString{String: a.quarantine, Valid: true}
+			}
+			if i == chosen {
+				status = db.ScrapeRunStatusSucceeded
+				err = storeErr
+			}
+			// A source that answered is healthy, even when its price went
+			// unused.
+			s.updateLastScraped(ctx, repo, symbol.ID, a.sourceID)
+		}
+		if err != nil {
+			status = db.ScrapeRunStatusFailed
+			symbolErr = err
+			slog.Warn("scraping symbol failed", "run_id", runID, "symbol", symbol.Symbol, "source", a.sourceID, "transient", IsTransient(err), "error", err)
+			if a.err != nil {
+				s.recordFailure(ctx, repo, symbol.ID, a.sourceID)
+			}
+		}
+		s.recordResult(ctx, repo, runResult, status, err)
+	}
+	if chosen != -1 {
+		symbolErr = storeErr
+	} else if symbolErr == nil && len(attempts) > 0 {
+		// Every source answered, and every price was quarantined.
+		symbolErr = errQuarantined
+	}
+	report.add(symbolErr)
+}
+
+// scrapeFromSource scrapes symbol within the source's limits.
+func (s *ScraperService) scrapeFromSource(ctx context.Context, symbol db.Symbol, source *passSource) (ScrapeResult, error) {
+	if source.makeErr != nil {
+		return ScrapeResult{}, source.makeErr
+	}
+	if err := source.gate.acquire(ctx); err != nil {
+		return ScrapeResult{}, err
+	}
+	defer source.gate.release()
+
+	scrapeResult, err := source.scraper.Scrape(ctx, symbol)
 	if err != nil {
-		return fmt.Errorf("error scraping symbol %+v: %w", symbol, err)
+		return ScrapeResult{}, fmt.Errorf("error scraping symbol %+v: %w", symbol, err)
 	}
+	return scrapeResult, nil
+}
+
+// isStale reports whether a scraped price is older than ScrapeStaleAfter. A
+// zero ScrapeStaleAfter only falls back on failure.
+func (s *ScraperService) isStale(result ScrapeResult) bool {
+	staleAfter := s.appContext.Config.ScrapeStaleAfter
+	return staleAfter > 0 && time.Since(result.Timestamp) > staleAfter
+}
 
-	err = repo.InsertPrice(ctx, symbol.ID, scrapeResult.Price, scrapeResult.Currency, scrapeResult.Timestamp)
+func (s *ScraperService) storePrice(ctx context.Context, repo *db.Repo, symbol db.Symbol, sourceID string, scrapeResult ScrapeResult) error {
+	err := repo.InsertPrice(ctx, db.Price{
+		SymbolID:  symbol.ID,
+		Price:     scrapeResult.Price,
+		Currency:  scrapeResult.Currency,
+		Timestamp: scrapeResult.Timestamp,
+		SourceID:  sql.NullString{String: sourceID, Valid: true},
+		ScrapedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
 	if err != nil {
 		return fmt.Errorf("error inserting price for symbol %+v: %w", symbol, err)
 	}
-	slog.Debug("scraped symbol", "symbol", symbol.Symbol, "price", scrapeResult.Price, "currency", scrapeResult.Currency, "timestamp", scrapeResult.Timestamp)
+	slog.Debug("scraped symbol", "symbol", symbol.Symbol, "source", sourceID, "price", scrapeResult.Price, "currency", scrapeResult.Currency, "timestamp", scrapeResult.Timestamp)
+	refreshPriceBars(ctx, repo, symbol.ID, scrapeResult.Timestamp)
+	s.appContext.Deps.QuoteService.ClearCache(ctx, symbol.Symbol)
+	return nil
+}
+
+// refreshPriceBars brings the symbol's bars up to date with a price stored at
+// timestamp. The price is stored either way, so failing is only logged; a
+// rebuild of the bars recovers.
+func refreshPriceBars(ctx context.Context, repo *db.Repo, symbolID int64, timestamp time.Time) {
+	if err := repo.RefreshPriceBars(ctx, symbolID, timestamp, timestamp); err != nil {
+		slog.Warn("refreshing price bars failed", "symbol_id", symbolID, "timestamp", timestamp, "error", err)
+	}
+}
 
-	err = repo.UpdateLastScraped(ctx, symbol.ID, scraper.SourceIdentifier(), time.Now().UTC())
+func (s *ScraperService) updateLastScraped(ctx context.Context, repo *db.Repo, symbolId int64, sourceIdentifier string) {
+	err := repo.UpdateLastScraped(ctx, symbolId, sourceIdentifier, time.Now().UTC())
 	if err != nil {
 		// not important if this fails, just log it, dont return the err
-		slog.Warn("updating last scraped timestamp failed", "symbol_id", symbol.ID, "source", scraper.SourceIdentifier(), "error", err)
+		slog.Warn("updating last scraped timestamp failed", "symbol_id", symbolId, "source", sourceIdentifier, "error", err)
 	}
-	s.appContext.Deps.QuoteService.ClearCache(ctx, symbol.Symbol)
+}
 
-	return nil
+// recordFailure counts the failure on the symbol source, deactivating it once
+// it has failed too many times in a row.
+func (s *ScraperService) recordFailure(ctx context.Context, repo *db.Repo, symbolId int64, sourceIdentifier string) {
+	deactivated, err := repo.RecordScrapeFailure(ctx, symbolId, sourceIdentifier, s.appContext.Config.ScrapeMaxConsecutiveFailures)
+	if err != nil {
+		slog.Warn("recording scrape failure failed", "symbol_id", symbolId, "source", sourceIdentifier, "error", err)
+		return
+	}
+	if deactivated {
+		slog.Warn("deactivated symbol source after consecutive failures", "symbol_id", symbolId, "source", sourceIdentifier, "failures", s.appContext.Config.ScrapeMaxConsecutiveFailures)
+	}
+}
+
+// recordResult stores the outcome of one symbol-source. Like last_scraped it is
+// bookkeeping, so a failure is logged rather than failing the run.
+func (s *ScraperService) recordResult(ctx context.Context, repo *db.Repo, result db.ScrapeRunResult, status string, scrapeErr error) {
+	result.ScrapedAt = time.Now().UTC()
+	result.Status = status
+	if scrapeErr != nil {
+		result.Error = sql.NullString{String: scrapeErr.Error(), Valid: true}
+	}
+	if err := repo.InsertScrapeRunResult(context.WithoutCancel(ctx), result); err != nil {
+		slog.Warn("recording scrape run result failed", "run_id", result.RunID, "symbol_id", result.SymbolID, "source", result.SourceID, "error", err)
+	}
+}
+
+func toCoreScrapeRun(r db.ScrapeRun) core.ScrapeRun {
+	run := core.ScrapeRun{
+		ID:             r.ID,
+		Trigger:        r.Trigger,
+		Status:         r.Status,
+		StartedAt:      r.StartedAt,
+		Error:          r.Error.String,
+		PricesInserted: r.PricesInserted,
+	}
+	if r.FinishedAt.Valid {
+		run.FinishedAt = &r.FinishedAt.Time
+	}
+	return run
+}
+
+func toCoreScrapeRunResult(rr db.ScrapeRunResultRow) core.ScrapeRunResult {
+	result := core.ScrapeRunResult{
+		Symbol:    rr.Symbol,
+		SourceID:  rr.SourceID,
+		Status:    rr.Status,
+		Error:     rr.Error.String,
+		Currency:  rr.Currency.String,
+		ScrapedAt: rr.ScrapedAt,
+	}
+	if rr.Price.Valid {
+		result.Price = &rr.Price.Decimal
+	}
+	if rr.Timestamp.Valid {
+		result.Timestamp = &rr.Timestamp.Time
+	}
+	return result
 }
@@ -0,0 +1,221 @@
+package scrapers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+	"github.com/shopspring/decimal"
+)
+
+// stubScraper answers every scrape with result, or err, and counts the calls.
+type stubScraper struct {
+	source string
+	result ScrapeResult
+	err    error
+	calls  int
+}
+
+func (s *stubScraper) Scrape(ctx context.Context, symbol db.Symbol) (ScrapeResult, error) {
+	s.calls++
+	return s.result, s.err
+}
+
+func (s *stubScraper) SourceIdentifier() string { return s.source }
+
+func TestScrapeSymbolFallsBack(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	fresh := func(price int64) ScrapeResult {
+		return ScrapeResult{Price: decimal.NewFromInt(price), Currency: "EUR", Timestamp: now.Add(-time.Minute)}
+	}
+	stale := func(price int64, age time.Duration) ScrapeResult {
+		return ScrapeResult{Price: decimal.NewFromInt(price), Currency: "EUR", Timestamp: now.Add(-age)}
+	}
+	failed := errors.New("source down")
+	tests := []struct {
+		name      string
+		stored    int64 // a price already stored, from source "seed"
+		scrapers  []*stubScraper
+		want      string
+		wantCalls []int
+		failed    int
+	}{
+		{
+			name:      "first fresh price wins",
+			scrapers:  []*stubScraper{{source: "a", result: fresh(10)}, {source: "b", result: fresh(11)}},
+			want:      "10 a",
+			wantCalls: []int{1, 0},
+		},
+		{
+			name:      "error falls back",
+			scrapers:  []*stubScraper{{source: "a", err: failed}, {source: "b", result: fresh(11)}},
+			want:      "11 b",
+			wantCalls: []int{1, 1},
+		},
+		{
+			name:      "stale price falls back",
+			scrapers:  []*stubScraper{{source: "a", result: stale(10, 3*time.Hour)}, {source: "b", result: fresh(11)}},
+			want:      "11 b",
+			wantCalls: []int{1, 1},
+		},
+		{
+			name: "newest stale price when all are stale",
+			scrapers: []*stubScraper{
+				{source: "a", result: stale(10, 5*time.Hour)},
+				{source: "b", err: failed},
+				{source: "c", result: stale(12, 3*time.Hour)},
+			},
+			want:      "12 c",
+			wantCalls: []int{1, 1, 1},
+		},
+		{
+			name:      "every price quarantined",
+			stored:    10,
+			scrapers:  []*stubScraper{{source: "a", result: fresh(100)}, {source: "b", result: fresh(50)}},
+			want:      "10 seed",
+			wantCalls: []int{1, 1},
+			failed:    1,
+		},
+		{
+			name:      "one price quarantined, the next stored",
+			stored:    10,
+			scrapers:  []*stubScraper{{source: "a", result: fresh(100)}, {source: "b", result: fresh(11)}},
+			want:      "11 b",
+			wantCalls: []int{1, 1},
+		},
+		{
+			name:      "every source fails",
+			scrapers:  []*stubScraper{{source: "a", err: failed}, {source: "b", err: failed}},
+			wantCalls: []int{1, 1},
+			failed:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, repo := newTestScraperService(t)
+			s.appContext.Config.ScrapeStaleAfter = 2 * time.Hour
+			symbol := insertTestSymbol(t, repo, "A")
+			if tt.stored != 0 {
+				err := repo.InsertPrice(ctx, db.Price{
+					SymbolID:  symbol.ID,
+					Price:     decimal.NewFromInt(tt.stored),
+					Currency:  "EUR",
+					Timestamp: now.Add(-time.Hour),
+					SourceID:  sql.NullString{String: "seed", Valid: true},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			runID, err := s.NewRun(ctx, core.ScrapeTriggerCLI)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var candidates []db.SymbolSource
+			sources := map[string]*passSource{}
+			for i, scraper := range tt.scrapers {
+				candidates = append(candidates, db.SymbolSource{SymbolID: symbol.ID, SourceID: scraper.source, Priority: i})
+				sources[scraper.source] = &passSource{
+					scraper: scraper,
+					gate:    &sourceGate{slots: make(chan struct{}, 1), limiter: newRateLimiter(0)},
+				}
+			}
+			report := &scrapeReport{}
+			s.scrapeSymbol(ctx, repo, runID, candidates, sources, false, report)
+
+			for i, scraper := range tt.scrapers {
+				if scraper.calls != tt.wantCalls[i] {
+					t.Errorf("source %v scraped %v times, want %v", scraper.source, scraper.calls, tt.wantCalls[i])
+				}
+			}
+			if report.attempted != 1 || report.failed != tt.failed {
+				t.Errorf("report %v attempted %v failed, want 1 and %v", report.attempted, report.failed, tt.failed)
+			}
+			last, err := repo.LatestPrice(ctx, symbol.ID)
+			got := ""
+			if err == nil {
+				got = last.Price.String() + " " + last.SourceID.String
+			}
+			if got != tt.want {
+				t.Errorf("stored %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// closedCalendar knows every exchange, and has them all closed.
+type closedCalendar struct{}
+
+func (closedCalendar) MarketStatus(exchangeID string, t time.Time) core.MarketStatus {
+	return core.MarketStatus{ExchangeID: exchangeID, Known: true}
+}
+
+func TestScrapeSymbolMarketClosed(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"symbol": "SAP.DE", "latestPrice": 170.5, "currency": "EUR", "timestamp": %q}`, now.Format(time.RFC3339))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	s, repo := newTestScraperService(t)
+	s.appContext.Deps.CalendarService = closedCalendar{}
+	if err := repo.InsertScrapingSource(ctx, db.ScrapingSource{ID: ScrapingSourceIdentifierYFINANCEAPI, Name: "yfinance", BaseUrl: srv.URL, MaxConcurrency: 1}); err != nil {
+		t.Fatal(err)
+	}
+	symbol := db.Symbol{Symbol: "SAP.DE", Isin: "DE0007164600", Active: true, ExchangeID: sql.NullString{String: "XETR", Valid: true}}
+	id, err := repo.InsertSymbol(ctx, symbol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.InsertSymbolSource(ctx, db.SymbolSource{SymbolID: id, SourceID: ScrapingSourceIdentifierYFINANCEAPI, Active: sql.NullBool{Bool: true, Valid: true}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		force      bool
+		wantErr    error
+		wantStatus string
+		wantStored string
+	}{
+		{force: false, wantErr: core.ErrMarketClosed, wantStatus: db.ScrapeRunStatusSkipped},
+		{force: true, wantStatus: db.ScrapeRunStatusSucceeded, wantStored: "170.5"},
+	}
+	for _, tt := range tests {
+		runID, err := s.NewRun(ctx, core.ScrapeTriggerCLI)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.ScrapeSymbol(ctx, runID, id, tt.force); !errors.Is(err, tt.wantErr) {
+			t.Errorf("force %v: got %v, want %v", tt.force, err, tt.wantErr)
+		}
+		run, err := s.Run(ctx, runID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run.Status != tt.wantStatus {
+			t.Errorf("force %v: run %v, want %v", tt.force, run.Status, tt.wantStatus)
+		}
+		if tt.wantErr != nil && !strings.Contains(run.Error, "market closed") {
+			t.Errorf("force %v: run error %q does not say why", tt.force, run.Error)
+		}
+		stored := ""
+		if last, err := repo.LatestPrice(ctx, id); err == nil {
+			stored = last.Price.String()
+		}
+		if stored != tt.wantStored {
+			t.Errorf("force %v: stored %q, want %q", tt.force, stored, tt.wantStored)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package scrapers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+	"github.com/shopspring/decimal"
+)
+
+// errInvalidPrice marks a scraped price that cannot be right, such as a zero
+// price or a timestamp in the future. It is rejected outright.
+var errInvalidPrice = errors.New("invalid scraped price")
+
+// errQuarantined is what a symbol reports when every price scraped for it was
+// quarantined, so that nothing was stored.
+var errQuarantined = errors.New("scraped price quarantined")
+
+// maxFutureSkew allows for a source's clock running somewhat ahead.
+const maxFutureSkew = time.Hour
+
+// quarantineReleaseReadings is how many consistent readings in a row make a
+// price that deviates from the last stored one the new normal, as after a
+// split or a stale feed resuming, rather than quarantining it forever.
+const quarantineReleaseReadings = 3
+
+// validate checks a scraped price before it is stored. It returns an error
+// wrapping errInvalidPrice for a price that cannot be right, and a reason for
+// one that merely looks wrong: a jump beyond ScrapeMaxDeviationPercent from the
+// last stored price, or a different currency. Those are quarantined for review,
+// unless the readings quarantined since the last stored price agree with it.
+func (s *ScraperService) validate(ctx context.Context, repo *db.Repo, symbol db.Symbol, result ScrapeResult) (string, error) {
+	switch {
+	case !result.Price.IsPositive():
+		return "", fmt.Errorf("%w: price %v is not positive", errInvalidPrice, result.Price)
+	case result.Currency == "":
+		return "", fmt.Errorf("%w: no currency", errInvalidPrice)
+	case result.Timestamp.IsZero():
+		return "", fmt.Errorf("%w: no timestamp", errInvalidPrice)
+	case result.Timestamp.After(time.Now().Add(maxFutureSkew)):
+		return "", fmt.Errorf("%w: timestamp %v is in the future", errInvalidPrice, result.Timestamp.Format(time.RFC3339))
+	}
+
+	last, err := repo.LatestPrice(ctx, symbol.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Nothing to compare a first price against.
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error getting last price for symbol %v: %w", symbol.Symbol, err)
+	}
+
+	reason := s.deviation(last.Price, last.Currency, result)
+	if reason == "" {
+		return "", nil
+	}
+	released, err := s.releasedByReadings(ctx, repo, symbol, last.Timestamp, result)
+	if err != nil {
+		return "", fmt.Errorf("error getting quarantined prices for symbol %v: %w", symbol.Symbol, err)
+	}
+	if released {
+		slog.Info("accepting deviating price after consistent readings", "symbol", symbol.Symbol, "price", result.Price, "currency", result.Currency, "reason", reason)
+		return "", nil
+	}
+	return reason, nil
+}
+
+// deviation is why result does not follow on from a reference price, or empty
+// if it does.
+func (s *ScraperService) deviation(price decimal.Decimal, currency string, result ScrapeResult) string {
+	if !strings.EqualFold(currency, result.Currency) {
+		return fmt.Sprintf("currency changed from %v to %v", currency, result.Currency)
+	}
+	maxDeviation := s.appContext.Config.ScrapeMaxDeviationPercent
+	if maxDeviation > 0 && price.IsPositive() {
+		deviation := result.Price.Sub(price).Abs().Mul(decimal.NewFromInt(100)).Div(price)
+		if deviation.GreaterThan(decimal.NewFromFloat(maxDeviation)) {
+			return fmt.Sprintf("price %v deviates %v%% from last price %v", result.Price, deviation.StringFixed(2), price)
+		}
+	}
+	return ""
+}
+
+// releasedByReadings reports whether the readings quarantined since the last
+// stored price, at since, make result the last of quarantineReleaseReadings
+// consistent readings. Those readings stay pending for review.
+func (s *ScraperService) releasedByReadings(ctx context.Context, repo *db.Repo, symbol db.Symbol, since time.Time, result ScrapeResult) (bool, error) {
+	earlier, err := repo.PendingQuarantinedPrices(ctx, symbol.ID, since, result.Timestamp, quarantineReleaseReadings-1)
+	if err != nil {
+		return false, err
+	}
+	if len(earlier) < quarantineReleaseReadings-1 {
+		return false, nil
+	}
+	for _, q := range earlier {
+		if s.deviation(q.Price, q.Currency, result) != "" {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,167 @@
+package scrapers
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/calendar"
+	"github.com/bjarke-xyz/stonks/internal/config"
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/quote"
+	"github.com/bjarke-xyz/stonks/internal/repository"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+	"github.com/shopspring/decimal"
+)
+
+// newTestScraperService returns a ScraperService on a migrated database in a
+// temporary directory, and a repo on that database.
+func newTestScraperService(t *testing.T) (*ScraperService, *db.Repo) {
+	t.Helper()
+	cfg := &config.Config{
+		DbConnStr:                 filepath.Join(t.TempDir(), "stonks.db"),
+		ScrapeMaxDeviationPercent: 20,
+	}
+	conn, err := db.Open(cfg)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.Migrate("up", conn, 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	repo, err := db.OpenRepo(cfg)
+	if err != nil {
+		t.Fatalf("open repo: %v", err)
+	}
+	appContext := &core.AppContext{
+		Config: cfg,
+		Deps: &core.AppDeps{
+			Cache:           repository.NewCacheService(repository.NewCacheRepo(cfg, true)),
+			CalendarService: calendar.NewCalendarService(),
+		},
+	}
+	appContext.Deps.QuoteService = quote.NewQuoteService(appContext)
+	return &ScraperService{appContext: appContext}, repo
+}
+
+func insertTestSymbol(t *testing.T, repo *db.Repo, ticker string) db.Symbol {
+	t.Helper()
+	symbol := db.Symbol{Symbol: ticker, Isin: ticker, Active: true}
+	id, err := repo.InsertSymbol(context.Background(), symbol)
+	if err != nil {
+		t.Fatalf("insert symbol: %v", err)
+	}
+	symbol.ID = id
+	return symbol
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	stored := now.Add(-time.Hour)
+	result := func(price string, currency string, timestamp time.Time) ScrapeResult {
+		return ScrapeResult{Price: decimal.RequireFromString(price), Currency: currency, Timestamp: timestamp}
+	}
+	type quarantined struct {
+		price     string
+		currency  string
+		timestamp time.Time
+	}
+	tests := []struct {
+		name        string
+		noPrice     bool
+		quarantined []quarantined
+		result      ScrapeResult
+		invalid     bool
+		quarantine  bool
+	}{
+		{name: "zero price", result: result("0", "EUR", now), invalid: true},
+		{name: "no currency", result: result("100", "", now), invalid: true},
+		{name: "future timestamp", result: result("100", "EUR", now.Add(2*time.Hour)), invalid: true},
+		{name: "first price", noPrice: true, result: result("1000", "USD", now)},
+		{name: "within the deviation", result: result("110", "EUR", now)},
+		{name: "beyond the deviation", result: result("150", "EUR", now), quarantine: true},
+		{name: "currency changed", result: result("100", "USD", now), quarantine: true},
+		{
+			name: "released by consistent readings",
+			quarantined: []quarantined{
+				{"200", "EUR", now.Add(-40 * time.Minute)},
+				{"210", "EUR", now.Add(-20 * time.Minute)},
+			},
+			result: result("205", "EUR", now),
+		},
+		{
+			name: "currency change released by consistent readings",
+			quarantined: []quarantined{
+				{"100", "USD", now.Add(-40 * time.Minute)},
+				{"100", "USD", now.Add(-20 * time.Minute)},
+			},
+			result: result("101", "USD", now),
+		},
+		{
+			name: "too few readings",
+			quarantined: []quarantined{
+				{"200", "EUR", now.Add(-20 * time.Minute)},
+			},
+			result:     result("205", "EUR", now),
+			quarantine: true,
+		},
+		{
+			name: "inconsistent readings",
+			quarantined: []quarantined{
+				{"300", "EUR", now.Add(-40 * time.Minute)},
+				{"200", "EUR", now.Add(-20 * time.Minute)},
+			},
+			result:     result("205", "EUR", now),
+			quarantine: true,
+		},
+		{
+			name: "readings from before the stored price",
+			quarantined: []quarantined{
+				{"200", "EUR", stored.Add(-40 * time.Minute)},
+				{"200", "EUR", stored.Add(-20 * time.Minute)},
+			},
+			result:     result("205", "EUR", now),
+			quarantine: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, repo := newTestScraperService(t)
+			symbol := insertTestSymbol(t, repo, "A")
+			if !tt.noPrice {
+				err := repo.InsertPrice(ctx, db.Price{SymbolID: symbol.ID, Price: decimal.NewFromInt(100), Currency: "EUR", Timestamp: stored})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, q := range tt.quarantined {
+				err := repo.InsertQuarantinedPrice(ctx, db.QuarantinedPrice{
+					SymbolID:  symbol.ID,
+					SourceID:  ScrapingSourceIdentifierYFINANCEAPI,
+					Price:     decimal.RequireFromString(q.price),
+					Currency:  q.currency,
+					Timestamp: q.timestamp,
+					ScrapedAt: q.timestamp,
+					Reason:    "test",
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			reason, err := s.validate(ctx, repo, symbol, tt.result)
+			if got := errors.Is(err, errInvalidPrice); got != tt.invalid {
+				t.Fatalf("invalid = %v (%v), want %v", got, err, tt.invalid)
+			}
+			if err != nil && !tt.invalid {
+				t.Fatal(err)
+			}
+			if got := reason != ""; got != tt.quarantine {
+				t.Errorf("quarantine = %v (%q), want %v", got, reason, tt.quarantine)
+			}
+		})
+	}
+}
@@ -2,10 +2,9 @@ package scrapers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/bjarke-xyz/stonks/internal/core"
@@ -17,7 +16,7 @@ type YFinanceAPIScraper struct {
 	appContext *core.AppContext
 }
 
-func NewYFinanceAPIScraper(appContext *core.AppContext) Scraper {
+func NewYFinanceAPIScraper(appContext *core.AppContext) HistoricalScraper {
 	return &YFinanceAPIScraper{appContext: appContext}
 }
 
@@ -40,39 +39,71 @@ func (y *YFinanceAPIScraper) Scrape(ctx context.Context, symbol db.Symbol) (Scra
 
 	url := fmt.Sprintf("%s/ticker/%s", scrapingSource.BaseUrl, symbol.Symbol)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return ScrapeResult{}, fmt.Errorf("error creating request: %w", err)
+	header := http.Header{}
+	if y.appContext.Config.YFinanceAPIAuthKey != "" {
+		header.Set("Authorization", y.appContext.Config.YFinanceAPIAuthKey)
 	}
 
-	if y.appContext.Config.YFinanceAPIAuthKey != "" {
-		req.Header.Set("Authorization", y.appContext.Config.YFinanceAPIAuthKey)
+	parsedResponse := yfinanceAPIResponse{}
+	err = defaultScrapeClient.getJSON(ctx, url, header, &parsedResponse)
+	if err != nil {
+		return ScrapeResult{}, err
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	return ScrapeResult{
+		Price:     parsedResponse.LatestPrice,
+		Currency:  parsedResponse.Currency,
+		Timestamp: parsedResponse.Timestamp,
+	}, nil
+}
+
+// ScrapeHistory implements HistoricalScraper. The history endpoint takes
+// inclusive start and end dates and answers with daily closes.
+func (y *YFinanceAPIScraper) ScrapeHistory(ctx context.Context, symbol db.Symbol, start time.Time, end time.Time) ([]ScrapeResult, error) {
+	repo, err := db.OpenRepo(y.appContext.Config)
 	if err != nil {
-		return ScrapeResult{}, fmt.Errorf("error HTTP GETting %v: %w", url, err)
+		return nil, fmt.Errorf("error opening db: %w", err)
 	}
 
-	defer resp.Body.Close()
-	bodyBytes, err := io.ReadAll(resp.Body)
+	scrapingSource, err := repo.ScrapingSourceByID(ctx, y.SourceIdentifier())
 	if err != nil {
-		return ScrapeResult{}, fmt.Errorf("error reading response body: %w", err)
+		return nil, fmt.Errorf("error getting scraping source: %w", err)
 	}
-	bodyStr := string(bodyBytes)
 
-	parsedResponse := yfinanceAPIResponse{}
-	err = json.Unmarshal(bodyBytes, &parsedResponse)
+	query := url.Values{}
+	query.Set("start", start.UTC().Format(time.DateOnly))
+	query.Set("end", end.UTC().Format(time.DateOnly))
+	historyURL := fmt.Sprintf("%s/ticker/%s/history?%s", scrapingSource.BaseUrl, symbol.Symbol, query.Encode())
+
+	header := http.Header{}
+	if y.appContext.Config.YFinanceAPIAuthKey != "" {
+		header.Set("Authorization", y.appContext.Config.YFinanceAPIAuthKey)
+	}
+
+	parsedResponse := yfinanceAPIHistoryResponse{}
+	err = defaultScrapeClient.getJSON(ctx, historyURL, header, &parsedResponse)
 	if err != nil {
-		return ScrapeResult{}, fmt.Errorf("error parsing response body (%v): %w", bodyStr, err)
+		return nil, err
 	}
 
-	return ScrapeResult{
-		Price:     parsedResponse.LatestPrice,
-		Currency:  parsedResponse.Currency,
-		Timestamp: parsedResponse.Timestamp,
-	}, nil
+	results := make([]ScrapeResult, 0, len(parsedResponse.Prices))
+	for _, p := range parsedResponse.Prices {
+		results = append(results, ScrapeResult{
+			Price:     p.Price,
+			Currency:  parsedResponse.Currency,
+			Timestamp: p.Timestamp,
+		})
+	}
+	return results, nil
+}
+
+type yfinanceAPIHistoryResponse struct {
+	Symbol   string `json:"symbol"`
+	Currency string `json:"currency"`
+	Prices   []struct {
+		Price     decimal.Decimal `json:"price"`
+		Timestamp time.Time       `json:"timestamp"`
+	} `json:"prices"`
 }
 
 type yfinanceAPIResponse struct {
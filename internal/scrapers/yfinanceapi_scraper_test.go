@@ -0,0 +1,78 @@
+package scrapers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+)
+
+func TestYFinanceScrapeHistory(t *testing.T) {
+	var gotPath, gotQuery, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotAuth = r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"symbol": "SAP.DE",
+			"currency": "EUR",
+			"prices": [
+				{"price": 170.12, "timestamp": "2024-03-01T16:30:00Z"},
+				{"price": "171.5", "timestamp": "2024-03-04T17:30:00+01:00"}
+			]
+		}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	s, repo := newTestScraperService(t)
+	s.appContext.Config.YFinanceAPIAuthKey = "secret"
+	if err := repo.InsertScrapingSource(ctx, db.ScrapingSource{ID: ScrapingSourceIdentifierYFINANCEAPI, Name: "yfinance", BaseUrl: srv.URL, MaxConcurrency: 1}); err != nil {
+		t.Fatal(err)
+	}
+	scraper := NewYFinanceAPIScraper(s.appContext)
+
+	// A local start still asks for its UTC date.
+	start := time.Date(2024, 3, 1, 0, 30, 0, 0, time.FixedZone("CET", 3600))
+	end := time.Date(2024, 3, 4, 23, 59, 59, 0, time.UTC)
+	results, err := scraper.ScrapeHistory(ctx, db.Symbol{Symbol: "SAP.DE"}, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/ticker/SAP.DE/history" || gotQuery != "end=2024-03-04&start=2024-02-29" {
+		t.Errorf("requested %v?%v, want /ticker/SAP.DE/history?end=2024-03-04&start=2024-02-29", gotPath, gotQuery)
+	}
+	if gotAuth != "secret" {
+		t.Errorf("Authorization = %q, want the configured key", gotAuth)
+	}
+
+	want := []string{"170.12 EUR 2024-03-01T16:30:00Z", "171.5 EUR 2024-03-04T16:30:00Z"}
+	if len(results) != len(want) {
+		t.Fatalf("got %v results, want %v", len(results), len(want))
+	}
+	for i, r := range results {
+		got := r.Price.String() + " " + r.Currency + " " + r.Timestamp.UTC().Format(time.RFC3339)
+		if got != want[i] {
+			t.Errorf("result %v = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestYFinanceScrapeHistoryError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such ticker", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	s, repo := newTestScraperService(t)
+	if err := repo.InsertScrapingSource(ctx, db.ScrapingSource{ID: ScrapingSourceIdentifierYFINANCEAPI, Name: "yfinance", BaseUrl: srv.URL, MaxConcurrency: 1}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewYFinanceAPIScraper(s.appContext).ScrapeHistory(ctx, db.Symbol{Symbol: "NOPE"}, time.Now().AddDate(0, 0, -7), time.Now())
+	if err == nil || IsTransient(err) {
+		t.Errorf("got %v, want a permanent error", err)
+	}
+}
@@ -0,0 +1,498 @@
+package symbols
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+	"github.com/bjarke-xyz/stonks/internal/scrapers"
+	"github.com/bjarke-xyz/stonks/pkg"
+	"github.com/samber/lo"
+)
+
+type SymbolService struct {
+	appContext *core.AppContext
+}
+
+func NewSymbolService(appContext *core.AppContext) core.SymbolService {
+	return &SymbolService{appContext: appContext}
+}
+
+// Symbols implements core.SymbolService.
+func (s *SymbolService) Symbols(ctx context.Context) ([]core.SymbolDetails, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return nil, fmt.Errorf("error opening db: %w", err)
+	}
+	dbSymbols, err := repo.Symbols(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting symbols: %w", err)
+	}
+	return lo.Map(dbSymbols, func(sym db.SymbolSummary, _ int) core.SymbolDetails { return toCoreSymbolSummary(sym) }), nil
+}
+
+// SearchSymbols implements core.SymbolService.
+func (s *SymbolService) SearchSymbols(ctx context.Context, query string, limit int) ([]core.SymbolDetails, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return nil, fmt.Errorf("error opening db: %w", err)
+	}
+	dbSymbols, err := repo.SearchSymbols(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching symbols: %w", err)
+	}
+	return lo.Map(dbSymbols, func(sym db.SymbolSummary, _ int) core.SymbolDetails { return toCoreSymbolSummary(sym) }), nil
+}
+
+// Symbol implements core.SymbolService.
+func (s *SymbolService) Symbol(ctx context.Context, id int64) (core.SymbolDetails, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return core.SymbolDetails{}, fmt.Errorf("error opening db: %w", err)
+	}
+	symbol, err := repo.SymbolByID(ctx, id)
+	if err != nil {
+		return core.SymbolDetails{}, fmt.Errorf("error getting symbol %v: %w", id, err)
+	}
+	return toCoreSymbol(symbol), nil
+}
+
+// CreateSymbol implements core.SymbolService.
+func (s *SymbolService) CreateSymbol(ctx context.Context, input core.SymbolInput) (core.SymbolDetails, error) {
+	if input.Symbol == nil || input.ISIN == nil {
+		return core.SymbolDetails{}, fmt.Errorf("%w: symbol and isin are required", core.ErrInvalidInput)
+	}
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return core.SymbolDetails{}, fmt.Errorf("error opening db: %w", err)
+	}
+	symbol := db.Symbol{Active: true}
+	if err := s.applySymbolInput(ctx, repo, &symbol, input); err != nil {
+		return core.SymbolDetails{}, err
+	}
+	symbol.ID, err = repo.InsertSymbol(ctx, symbol)
+	if err != nil {
+		return core.SymbolDetails{}, fmt.Errorf("error inserting symbol: %w", err)
+	}
+	return toCoreSymbol(symbol), nil
+}
+
+// UpdateSymbol implements core.SymbolService.
+func (s *SymbolService) UpdateSymbol(ctx context.Context, id int64, input core.SymbolInput) (core.SymbolDetails, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return core.SymbolDetails{}, fmt.Errorf("error opening db: %w", err)
+	}
+	symbol, err := repo.SymbolByID(ctx, id)
+	if err != nil {
+		return core.SymbolDetails{}, fmt.Errorf("error getting symbol %v: %w", id, err)
+	}
+	oldTicker := symbol.Symbol
+	if err := s.applySymbolInput(ctx, repo, &symbol, input); err != nil {
+		return core.SymbolDetails{}, err
+	}
+	if err := repo.UpdateSymbol(ctx, symbol); err != nil {
+		return core.SymbolDetails{}, fmt.Errorf("error updating symbol %v: %w", id, err)
+	}
+	s.appContext.Deps.QuoteService.ClearCache(ctx, oldTicker)
+	return toCoreSymbol(symbol), nil
+}
+
+// DeleteSymbol implements core.SymbolService.
+func (s *SymbolService) DeleteSymbol(ctx context.Context, id int64) error {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return fmt.Errorf("error opening db: %w", err)
+	}
+	symbol, err := repo.SymbolByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("error getting symbol %v: %w", id, err)
+	}
+	deleted, err := repo.DeleteSymbol(ctx, id)
+	if err != nil {
+		return fmt.Errorf("error deleting symbol %v: %w", id, err)
+	}
+	if !deleted {
+		return fmt.Errorf("error deleting symbol %v: %w", id, sql.ErrNoRows)
+	}
+	s.appContext.Deps.QuoteService.ClearCache(ctx, symbol.Symbol)
+	return nil
+}
+
+// applySymbolInput validates the fields set in input and copies them to
+// symbol. A ticker must be unique.
+func (s *SymbolService) applySymbolInput(ctx context.Context, repo *db.Repo, symbol *db.Symbol, input core.SymbolInput) error {
+	if input.Symbol != nil {
+		ticker := strings.ToUpper(strings.TrimSpace(*input.Symbol))
+		// Tickers are used in URL paths and comma separated lists.
+		if ticker == "" || strings.ContainsAny(ticker, " \t\n/,?#") {
+			return fmt.Errorf("%w: invalid symbol %q", core.ErrInvalidInput, *input.Symbol)
+		}
+		existing, err := repo.SymbolByTicker(ctx, ticker)
+		if err == nil && existing.ID != symbol.ID {
+			return fmt.Errorf("%w: symbol %v", core.ErrAlreadyExists, ticker)
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error getting symbol %v: %w", ticker, err)
+		}
+		symbol.Symbol = ticker
+	}
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		symbol.Name = sql.NullString{String: name, Valid: name != ""}
+	}
+	if input.ISIN != nil {
+		isin := strings.ToUpper(strings.TrimSpace(*input.ISIN))
+		if err := pkg.ValidateISIN(isin); err != nil {
+			return fmt.Errorf("%w: %w", core.ErrInvalidInput, err)
+		}
+		symbol.Isin = isin
+	}
+	if input.Active != nil {
+		symbol.Active = *input.Active
+	}
+	if input.ExchangeID != nil {
+		exchangeID := strings.ToUpper(strings.TrimSpace(*input.ExchangeID))
+		if exchangeID != "" {
+			_, err := repo.ExchangeByID(ctx, exchangeID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: exchange %v does not exist", core.ErrInvalidInput, exchangeID)
+			}
+			if err != nil {
+				return fmt.Errorf("error getting exchange %v: %w", exchangeID, err)
+			}
+		}
+		symbol.ExchangeID = sql.NullString{String: exchangeID, Valid: exchangeID != ""}
+	}
+	return nil
+}
+
+// Exchanges implements core.SymbolService.
+func (s *SymbolService) Exchanges(ctx context.Context) ([]core.Exchange, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return nil, fmt.Errorf("error opening db: %w", err)
+	}
+	dbExchanges, err := repo.Exchanges(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting exchanges: %w", err)
+	}
+	return lo.Map(dbExchanges, func(e db.Exchange, _ int) core.Exchange {
+		return core.Exchange{ID: e.ID, Name: e.Name, Timezone: e.Timezone}
+	}), nil
+}
+
+// Sources implements core.SymbolService.
+func (s *SymbolService) Sources(ctx context.Context) ([]core.ScrapingSource, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return nil, fmt.Errorf("error opening db: %w", err)
+	}
+	dbSources, err := repo.ScrapingSources(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting scraping sources: %w", err)
+	}
+	return lo.Map(dbSources, func(src db.ScrapingSource, _ int) core.ScrapingSource { return toCoreScrapingSource(src) }), nil
+}
+
+// Source implements core.SymbolService.
+func (s *SymbolService) Source(ctx context.Context, id string) (core.ScrapingSource, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return core.ScrapingSource{}, fmt.Errorf("error opening db: %w", err)
+	}
+	source, err := repo.ScrapingSourceByID(ctx, id)
+	if err != nil {
+		return core.ScrapingSource{}, fmt.Errorf("error getting scraping source %v: %w", id, err)
+	}
+	return toCoreScrapingSource(source), nil
+}
+
+// CreateSource implements core.SymbolService.
+func (s *SymbolService) CreateSource(ctx context.Context, input core.ScrapingSourceInput) (core.ScrapingSource, error) {
+	if input.ID == nil || input.Name == nil || input.BaseURL == nil {
+		return core.ScrapingSource{}, fmt.Errorf("%w: id, name and baseUrl are required", core.ErrInvalidInput)
+	}
+	id := *input.ID
+	if _, err := scrapers.MakeScraper(id, s.appContext); err != nil {
+		return core.ScrapingSource{}, fmt.Errorf("%w: %w", core.ErrInvalidInput, err)
+	}
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return core.ScrapingSource{}, fmt.Errorf("error opening db: %w", err)
+	}
+	_, err = repo.ScrapingSourceByID(ctx, id)
+	if err == nil {
+		return core.ScrapingSource{}, fmt.Errorf("%w: scraping source %v", core.ErrAlreadyExists, id)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return core.ScrapingSource{}, fmt.Errorf("error getting scraping source %v: %w", id, err)
+	}
+
+	source := db.ScrapingSource{ID: id, MaxConcurrency: 1}
+	if err := applyScrapingSourceInput(&source, input); err != nil {
+		return core.ScrapingSource{}, err
+	}
+	if err := repo.InsertScrapingSource(ctx, source); err != nil {
+		return core.ScrapingSource{}, fmt.Errorf("error inserting scraping source: %w", err)
+	}
+	return toCoreScrapingSource(source), nil
+}
+
+// UpdateSource implements core.SymbolService.
+func (s *SymbolService) UpdateSource(ctx context.Context, id string, input core.ScrapingSourceInput) (core.ScrapingSource, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return core.ScrapingSource{}, fmt.Errorf("error opening db: %w", err)
+	}
+	source, err := repo.ScrapingSourceByID(ctx, id)
+	if err != nil {
+		return core.ScrapingSource{}, fmt.Errorf("error getting scraping source %v: %w", id, err)
+	}
+	if err := applyScrapingSourceInput(&source, input); err != nil {
+		return core.ScrapingSource{}, err
+	}
+	if err := repo.UpdateScrapingSource(ctx, source); err != nil {
+		return core.ScrapingSource{}, fmt.Errorf("error updating scraping source %v: %w", id, err)
+	}
+	return toCoreScrapingSource(source), nil
+}
+
+// DeleteSource implements core.SymbolService.
+func (s *SymbolService) DeleteSource(ctx context.Context, id string) error {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return fmt.Errorf("error opening db: %w", err)
+	}
+	deleted, err := repo.DeleteScrapingSource(ctx, id)
+	if err != nil {
+		return fmt.Errorf("error deleting scraping source %v: %w", id, err)
+	}
+	if !deleted {
+		return fmt.Errorf("error deleting scraping source %v: %w", id, sql.ErrNoRows)
+	}
+	return nil
+}
+
+func applyScrapingSourceInput(source *db.ScrapingSource, input core.ScrapingSourceInput) error {
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			return fmt.Errorf("%w: name must not be empty", core.ErrInvalidInput)
+		}
+		source.Name = name
+	}
+	if input.BaseURL != nil {
+		if err := validateURL(*input.BaseURL); err != nil {
+			return err
+		}
+		source.BaseUrl = *input.BaseURL
+	}
+	if input.AdditionalInfo != nil {
+		source.AdditionalInfo = sql.NullString{String: *input.AdditionalInfo, Valid: *input.AdditionalInfo != ""}
+	}
+	if input.MaxConcurrency != nil {
+		if *input.MaxConcurrency < 1 {
+			return fmt.Errorf("%w: maxConcurrency must be at least 1", core.ErrInvalidInput)
+		}
+		source.MaxConcurrency = *input.MaxConcurrency
+	}
+	if input.RequestsPerSecond != nil {
+		if *input.RequestsPerSecond < 0 {
+			return fmt.Errorf("%w: requestsPerSecond must not be negative", core.ErrInvalidInput)
+		}
+		source.RequestsPerSecond = sql.NullFloat64{Float64: *input.RequestsPerSecond, Valid: *input.RequestsPerSecond > 0}
+	}
+	return nil
+}
+
+// SymbolSources implements core.SymbolService.
+func (s *SymbolService) SymbolSources(ctx context.Context, symbolID int64) ([]core.SymbolSource, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return nil, fmt.Errorf("error opening db: %w", err)
+	}
+	if _, err := repo.SymbolByID(ctx, symbolID); err != nil {
+		return nil, fmt.Errorf("error getting symbol %v: %w", symbolID, err)
+	}
+	dbSources, err := repo.SymbolSourcesBySymbol(ctx, symbolID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting sources of symbol %v: %w", symbolID, err)
+	}
+	return lo.Map(dbSources, func(ss db.SymbolSource, _ int) core.SymbolSource { return toCoreSymbolSource(ss) }), nil
+}
+
+// CreateSymbolSource implements core.SymbolService.
+func (s *SymbolService) CreateSymbolSource(ctx context.Context, symbolID int64, input core.SymbolSourceInput) (core.SymbolSource, error) {
+	if input.SourceID == nil || input.ScrapeURL == nil {
+		return core.SymbolSource{}, fmt.Errorf("%w: sourceId and scrapeUrl are required", core.ErrInvalidInput)
+	}
+	sourceID := *input.SourceID
+	if _, err := scrapers.MakeScraper(sourceID, s.appContext); err != nil {
+		return core.SymbolSource{}, fmt.Errorf("%w: %w", core.ErrInvalidInput, err)
+	}
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return core.SymbolSource{}, fmt.Errorf("error opening db: %w", err)
+	}
+	if _, err := repo.SymbolByID(ctx, symbolID); err != nil {
+		return core.SymbolSource{}, fmt.Errorf("error getting symbol %v: %w", symbolID, err)
+	}
+	_, err = repo.ScrapingSourceByID(ctx, sourceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return core.SymbolSource{}, fmt.Errorf("%w: scraping source %v does not exist", core.ErrInvalidInput, sourceID)
+	}
+	if err != nil {
+		return core.SymbolSource{}, fmt.Errorf("error getting scraping source %v: %w", sourceID, err)
+	}
+	_, err = repo.SymbolSource(ctx, symbolID, sourceID)
+	if err == nil {
+		return core.SymbolSource{}, fmt.Errorf("%w: symbol %v already uses source %v", core.ErrAlreadyExists, symbolID, sourceID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return core.SymbolSource{}, fmt.Errorf("error getting symbol source: %w", err)
+	}
+
+	symbolSource := db.SymbolSource{SymbolID: symbolID, SourceID: sourceID, Active: sql.NullBool{Bool: true, Valid: true}}
+	if err := applySymbolSourceInput(&symbolSource, input); err != nil {
+		return core.SymbolSource{}, err
+	}
+	symbolSource.ID, err = repo.InsertSymbolSource(ctx, symbolSource)
+	if err != nil {
+		return core.SymbolSource{}, fmt.Errorf("error inserting symbol source: %w", err)
+	}
+	return toCoreSymbolSource(symbolSource), nil
+}
+
+// UpdateSymbolSource implements core.SymbolService.
+func (s *SymbolService) UpdateSymbolSource(ctx context.Context, symbolID int64, id int64, input core.SymbolSourceInput) (core.SymbolSource, error) {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return core.SymbolSource{}, fmt.Errorf("error opening db: %w", err)
+	}
+	symbolSource, err := symbolSourceOf(ctx, repo, symbolID, id)
+	if err != nil {
+		return core.SymbolSource{}, err
+	}
+	if err := applySymbolSourceInput(&symbolSource, input); err != nil {
+		return core.SymbolSource{}, err
+	}
+	if err := repo.UpdateSymbolSource(ctx, symbolSource); err != nil {
+		return core.SymbolSource{}, fmt.Errorf("error updating symbol source %v: %w", id, err)
+	}
+	return toCoreSymbolSource(symbolSource), nil
+}
+
+// DeleteSymbolSource implements core.SymbolService.
+func (s *SymbolService) DeleteSymbolSource(ctx context.Context, symbolID int64, id int64) error {
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		return fmt.Errorf("error opening db: %w", err)
+	}
+	if _, err := symbolSourceOf(ctx, repo, symbolID, id); err != nil {
+		return err
+	}
+	deleted, err := repo.DeleteSymbolSource(ctx, id)
+	if err != nil {
+		return fmt.Errorf("error deleting symbol source %v: %w", id, err)
+	}
+	if !deleted {
+		return fmt.Errorf("error deleting symbol source %v: %w", id, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// symbolSourceOf gets the symbol source, failing with sql.ErrNoRows unless it
+// belongs to the symbol.
+func symbolSourceOf(ctx context.Context, repo *db.Repo, symbolID int64, id int64) (db.SymbolSource, error) {
+	symbolSource, err := repo.SymbolSourceByID(ctx, id)
+	if err == nil && symbolSource.SymbolID != symbolID {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		return db.SymbolSource{}, fmt.Errorf("error getting symbol source %v: %w", id, err)
+	}
+	return symbolSource, nil
+}
+
+func applySymbolSourceInput(symbolSource *db.SymbolSource, input core.SymbolSourceInput) error {
+	if input.ScrapeURL != nil {
+		if err := validateURL(*input.ScrapeURL); err != nil {
+			return err
+		}
+		symbolSource.ScrapeUrl = *input.ScrapeURL
+	}
+	if input.Active != nil {
+		if *input.Active && !symbolSource.Active.Bool {
+			// A source deactivated for failing gets a fresh start.
+			symbolSource.ConsecutiveFailures = 0
+		}
+		symbolSource.Active = sql.NullBool{Bool: *input.Active, Valid: true}
+	}
+	if input.Priority != nil {
+		symbolSource.Priority = *input.Priority
+	}
+	return nil
+}
+
+func validateURL(rawURL string) error {
+	u, err := url.ParseRequestURI(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q is not an http(s) URL", core.ErrInvalidInput, rawURL)
+	}
+	return nil
+}
+
+func toCoreSymbol(s db.Symbol) core.SymbolDetails {
+	return core.SymbolDetails{
+		ID:         s.ID,
+		Symbol:     s.Symbol,
+		Name:       s.Name.String,
+		ISIN:       s.Isin,
+		Active:     s.Active,
+		ExchangeID: s.ExchangeID.String,
+	}
+}
+
+func toCoreSymbolSummary(s db.SymbolSummary) core.SymbolDetails {
+	symbol := toCoreSymbol(s.Symbol)
+	if s.LastScraped.Valid {
+		symbol.LastScraped = &s.LastScraped.Time
+	}
+	return symbol
+}
+
+func toCoreScrapingSource(s db.ScrapingSource) core.ScrapingSource {
+	source := core.ScrapingSource{
+		ID:             s.ID,
+		Name:           s.Name,
+		BaseURL:        s.BaseUrl,
+		AdditionalInfo: s.AdditionalInfo.String,
+		MaxConcurrency: s.MaxConcurrency,
+	}
+	if s.RequestsPerSecond.Valid {
+		source.RequestsPerSecond = &s.RequestsPerSecond.Float64
+	}
+	return source
+}
+
+func toCoreSymbolSource(s db.SymbolSource) core.SymbolSource {
+	symbolSource := core.SymbolSource{
+		ID:                  s.ID,
+		SymbolID:            s.SymbolID,
+		SourceID:            s.SourceID,
+		ScrapeURL:           s.ScrapeUrl,
+		Active:              s.Active.Bool,
+		Priority:            s.Priority,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+	}
+	if s.LastScraped.Valid {
+		symbolSource.LastScraped = &s.LastScraped.Time
+	}
+	return symbolSource
+}
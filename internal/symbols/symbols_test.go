@@ -0,0 +1,221 @@
+package symbols
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bjarke-xyz/stonks/internal/config"
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/quote"
+	"github.com/bjarke-xyz/stonks/internal/repository"
+	"github.com/bjarke-xyz/stonks/internal/repository/db"
+	"github.com/bjarke-xyz/stonks/internal/scrapers"
+	"github.com/shopspring/decimal"
+)
+
+func newTestSymbolService(t *testing.T) (*SymbolService, *sql.DB) {
+	t.Helper()
+	cfg := &config.Config{DbConnStr: filepath.Join(t.TempDir(), "stonks.db")}
+	conn, err := db.Open(cfg)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.Migrate("up", conn, 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	appContext := &core.AppContext{
+		Config: cfg,
+		Deps:   &core.AppDeps{Cache: repository.NewCacheService(repository.NewCacheRepo(cfg, true))},
+	}
+	appContext.Deps.QuoteService = quote.NewQuoteService(appContext)
+	return &SymbolService{appContext: appContext}, conn
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestCreateSymbol(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   core.SymbolInput
+		wantErr error
+		want    string
+	}{
+		{name: "ticker is upper cased", input: core.SymbolInput{Symbol: ptr(" eunl "), ISIN: ptr("IE00B4L5Y983"), ExchangeID: ptr("xetr")}, want: "EUNL"},
+		{name: "isin is required", input: core.SymbolInput{Symbol: ptr("SAP")}, wantErr: core.ErrInvalidInput},
+		{name: "ticker without spaces", input: core.SymbolInput{Symbol: ptr("S AP"), ISIN: ptr("DE0007164600")}, wantErr: core.ErrInvalidInput},
+		{name: "ticker without commas", input: core.SymbolInput{Symbol: ptr("SAP,"), ISIN: ptr("DE0007164600")}, wantErr: core.ErrInvalidInput},
+		{name: "isin check digit", input: core.SymbolInput{Symbol: ptr("SAP"), ISIN: ptr("DE0007164601")}, wantErr: core.ErrInvalidInput},
+		{name: "unknown exchange", input: core.SymbolInput{Symbol: ptr("SAP"), ISIN: ptr("DE0007164600"), ExchangeID: ptr("NOPE")}, wantErr: core.ErrInvalidInput},
+		{name: "ticker taken", input: core.SymbolInput{Symbol: ptr("aapl"), ISIN: ptr("DE0007164600")}, wantErr: core.ErrAlreadyExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, _ := newTestSymbolService(t)
+			if _, err := s.CreateSymbol(ctx, core.SymbolInput{Symbol: ptr("AAPL"), ISIN: ptr("US0378331005")}); err != nil {
+				t.Fatal(err)
+			}
+			symbol, err := s.CreateSymbol(ctx, tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (symbol.Symbol != tt.want || !symbol.Active) {
+				t.Errorf("got symbol %v active %v, want %v active", symbol.Symbol, symbol.Active, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateSymbol(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestSymbolService(t)
+	if _, err := s.CreateSymbol(ctx, core.SymbolInput{Symbol: ptr("AAPL"), ISIN: ptr("US0378331005")}); err != nil {
+		t.Fatal(err)
+	}
+	sap, err := s.CreateSymbol(ctx, core.SymbolInput{Symbol: ptr("SAP"), ISIN: ptr("DE0007164600")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := s.UpdateSymbol(ctx, sap.ID, core.SymbolInput{Name: ptr("SAP SE"), Active: ptr(false), Symbol: ptr("sap")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "SAP SE" || updated.Active || updated.ISIN != "DE0007164600" {
+		t.Errorf("got %+v, want the name and active flag changed only", updated)
+	}
+	if _, err := s.UpdateSymbol(ctx, sap.ID, core.SymbolInput{Symbol: ptr("AAPL")}); !errors.Is(err, core.ErrAlreadyExists) {
+		t.Errorf("renaming to a taken ticker: got %v, want ErrAlreadyExists", err)
+	}
+	if _, err := s.UpdateSymbol(ctx, 999, core.SymbolInput{Name: ptr("x")}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("updating an unknown symbol: got %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestSources(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestSymbolService(t)
+	source := core.ScrapingSourceInput{ID: ptr(scrapers.ScrapingSourceIdentifierYFINANCEAPI), Name: ptr("yfinance"), BaseURL: ptr("https://example.com")}
+	if _, err := s.CreateSource(ctx, source); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateSource(ctx, source); !errors.Is(err, core.ErrAlreadyExists) {
+		t.Errorf("creating a source twice: got %v, want ErrAlreadyExists", err)
+	}
+	unknown := core.ScrapingSourceInput{ID: ptr("NOPE"), Name: ptr("nope"), BaseURL: ptr("https://example.com")}
+	if _, err := s.CreateSource(ctx, unknown); !errors.Is(err, core.ErrInvalidInput) {
+		t.Errorf("creating a source without a scraper: got %v, want ErrInvalidInput", err)
+	}
+	if _, err := s.UpdateSource(ctx, scrapers.ScrapingSourceIdentifierYFINANCEAPI, core.ScrapingSourceInput{BaseURL: ptr("ftp://example.com")}); !errors.Is(err, core.ErrInvalidInput) {
+		t.Errorf("updating to a non-http url: got %v, want ErrInvalidInput", err)
+	}
+
+	symbol, err := s.CreateSymbol(ctx, core.SymbolInput{Symbol: ptr("AAPL"), ISIN: ptr("US0378331005")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := s.CreateSymbol(ctx, core.SymbolInput{Symbol: ptr("SAP"), ISIN: ptr("DE0007164600")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := core.SymbolSourceInput{SourceID: ptr(scrapers.ScrapingSourceIdentifierYFINANCEAPI), ScrapeURL: ptr("https://example.com/AAPL")}
+	symbolSource, err := s.CreateSymbolSource(ctx, symbol.ID, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateSymbolSource(ctx, symbol.ID, input); !errors.Is(err, core.ErrAlreadyExists) {
+		t.Errorf("attaching a source twice: got %v, want ErrAlreadyExists", err)
+	}
+	borsfra := core.SymbolSourceInput{SourceID: ptr(scrapers.ScrapingSourceIdentifierBORSFRA), ScrapeURL: ptr("https://example.com/AAPL")}
+	if _, err := s.CreateSymbolSource(ctx, symbol.ID, borsfra); !errors.Is(err, core.ErrInvalidInput) {
+		t.Errorf("attaching a source that was never created: got %v, want ErrInvalidInput", err)
+	}
+
+	if err := s.DeleteSymbolSource(ctx, other.ID, symbolSource.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleting through another symbol: got %v, want sql.ErrNoRows", err)
+	}
+	if err := s.DeleteSymbolSource(ctx, symbol.ID, symbolSource.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteSymbolSource(ctx, symbol.ID, symbolSource.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleting twice: got %v, want sql.ErrNoRows", err)
+	}
+
+	if _, err := s.CreateSymbolSource(ctx, symbol.ID, input); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteSource(ctx, scrapers.ScrapingSourceIdentifierYFINANCEAPI); err != nil {
+		t.Fatal(err)
+	}
+	sources, err := s.SymbolSources(ctx, symbol.ID)
+	if err != nil || len(sources) != 0 {
+		t.Errorf("got %v symbol sources (%v) after deleting the source, want 0", len(sources), err)
+	}
+	if err := s.DeleteSource(ctx, scrapers.ScrapingSourceIdentifierYFINANCEAPI); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleting a source twice: got %v, want sql.ErrNoRows", err)
+	}
+}
+
+// Foreign keys are not enforced, so DeleteSymbol must delete the dependent
+// rows itself.
+func TestDeleteSymbolCascades(t *testing.T) {
+	ctx := context.Background()
+	s, conn := newTestSymbolService(t)
+	repo, err := db.OpenRepo(s.appContext.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateSource(ctx, core.ScrapingSourceInput{ID: ptr(scrapers.ScrapingSourceIdentifierYFINANCEAPI), Name: ptr("yfinance"), BaseURL: ptr("https://example.com")}); err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, input := range []core.SymbolInput{
+		{Symbol: ptr("AAPL"), ISIN: ptr("US0378331005")},
+		{Symbol: ptr("SAP"), ISIN: ptr("DE0007164600")},
+	} {
+		symbol, err := s.CreateSymbol(ctx, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, symbol.ID)
+		if _, err := s.CreateSymbolSource(ctx, symbol.ID, core.SymbolSourceInput{SourceID: ptr(scrapers.ScrapingSourceIdentifierYFINANCEAPI), ScrapeURL: ptr("https://example.com")}); err != nil {
+			t.Fatal(err)
+		}
+		timestamp := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+		if err := repo.InsertPrice(ctx, db.Price{SymbolID: symbol.ID, Price: decimal.NewFromInt(1), Currency: "EUR", Timestamp: timestamp}); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.RefreshPriceBars(ctx, symbol.ID, timestamp, timestamp); err != nil {
+			t.Fatal(err)
+		}
+		err = repo.InsertQuarantinedPrice(ctx, db.QuarantinedPrice{SymbolID: symbol.ID, SourceID: scrapers.ScrapingSourceIdentifierYFINANCEAPI, Price: decimal.NewFromInt(2), Currency: "EUR", Timestamp: timestamp, ScrapedAt: timestamp, Reason: "test"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.InsertScrapeRunResult(ctx, db.ScrapeRunResult{RunID: 1, SymbolID: symbol.ID, SourceID: scrapers.ScrapingSourceIdentifierYFINANCEAPI, Status: db.ScrapeRunStatusSucceeded}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.DeleteSymbol(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"prices", "price_bars", "symbol_sources", "scrape_run_results", "quarantined_prices"} {
+		for i, want := range []int{0, 1} {
+			var count int
+			if err := conn.QueryRow(`SELECT COUNT(*) > 0 FROM `+table+` WHERE symbol_id = ?`, ids[i]).Scan(&count); err != nil {
+				t.Fatal(err)
+			}
+			if count != want {
+				t.Errorf("%v of symbol %v: got rows %v, want %v", table, i, count, want)
+			}
+		}
+	}
+	if err := s.DeleteSymbol(ctx, ids[0]); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleting twice: got %v, want sql.ErrNoRows", err)
+	}
+}
@@ -0,0 +1,177 @@
+package web
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/web/views"
+	"github.com/shopspring/decimal"
+)
+
+// timestampFormats are the accepted values of ?timestampFormat. Spreadsheets
+// parse "datetime" and "date" as dates in most locales.
+var timestampFormats = map[string]string{
+	"rfc3339":  time.RFC3339,
+	"datetime": time.DateTime,
+	"date":     time.DateOnly,
+	"unix":     "",
+}
+
+// csvOptions controls the CSV output, so it can match the spreadsheet's
+// locale: ?delimiter (a single character, "tab" or "semicolon", since a bare
+// ";" is not a valid query separator), ?decimal ("." or ",")
+// and ?timestampFormat (a key of timestampFormats).
+type csvOptions struct {
+	delimiter       rune
+	decimal         string
+	timestampFormat string
+}
+
+func parseCSVOptions(r *http.Request) (csvOptions, error) {
+	opts := csvOptions{delimiter: ',', decimal: ".", timestampFormat: "rfc3339"}
+
+	switch delimiter := queryOr(r, "delimiter", ","); {
+	case delimiter == "tab":
+		opts.delimiter = '\t'
+	case delimiter == "semicolon":
+		opts.delimiter = ';'
+	case utf8.RuneCountInString(delimiter) == 1:
+		opts.delimiter, _ = utf8.DecodeRuneInString(delimiter)
+		if opts.delimiter == '"' || opts.delimiter == '\r' || opts.delimiter == '\n' || opts.delimiter == utf8.RuneError {
+			return csvOptions{}, fmt.Errorf("invalid delimiter %q", delimiter)
+		}
+	default:
+		return csvOptions{}, fmt.Errorf("invalid delimiter %q, want a single character, \"tab\" or \"semicolon\"", delimiter)
+	}
+
+	opts.decimal = queryOr(r, "decimal", ".")
+	if opts.decimal != "." && opts.decimal != "," {
+		return csvOptions{}, fmt.Errorf("invalid decimal separator %q, want \".\" or \",\"", opts.decimal)
+	}
+
+	opts.timestampFormat = queryOr(r, "timestampFormat", "rfc3339")
+	if _, ok := timestampFormats[opts.timestampFormat]; !ok {
+		return csvOptions{}, fmt.Errorf("invalid timestamp format %q, want rfc3339, datetime, date or unix", opts.timestampFormat)
+	}
+	return opts, nil
+}
+
+func (o csvOptions) formatTimestamp(t time.Time) string {
+	if o.timestampFormat == "unix" {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return t.UTC().Format(timestampFormats[o.timestampFormat])
+}
+
+func (o csvOptions) formatScrapedAt(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return o.formatTimestamp(*t)
+}
+
+func (o csvOptions) formatDecimal(d decimal.Decimal) string {
+	return strings.Replace(d.String(), ".", o.decimal, 1)
+}
+
+// newCSVWriter starts a CSV response. Fields containing the delimiter, such
+// as a decimal comma with a comma delimiter, are quoted.
+func newCSVWriter(w http.ResponseWriter, opts csvOptions) *csv.Writer {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.delimiter
+	return cw
+}
+
+// writeCSV streams prices with a header row.
+func writeCSV(w http.ResponseWriter, prices []core.SimplePrice, opts csvOptions, showProvenance bool) error {
+	cw := newCSVWriter(w, opts)
+	header := []string{"timestamp", "price", "currency"}
+	if showProvenance {
+		header = append(header, "source", "scrapedAt")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, p := range prices {
+		record := []string{opts.formatTimestamp(p.Timestamp), opts.formatDecimal(p.Price), p.Currency}
+		if showProvenance {
+			record = append(record, p.Source, opts.formatScrapedAt(p.ScrapedAt))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeBarsCSV streams OHLC bars with a header row, each stamped with the
+// start of its interval.
+func writeBarsCSV(w http.ResponseWriter, bars []core.Bar, opts csvOptions) error {
+	cw := newCSVWriter(w, opts)
+	if err := cw.Write([]string{"timestamp", "open", "high", "low", "close", "currency", "count"}); err != nil {
+		return err
+	}
+	for _, b := range bars {
+		record := []string{
+			opts.formatTimestamp(b.Start),
+			opts.formatDecimal(b.Open),
+			opts.formatDecimal(b.High),
+			opts.formatDecimal(b.Low),
+			opts.formatDecimal(b.Close),
+			b.Currency,
+			strconv.Itoa(b.Count),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeQuotesCSV writes one record per row, like the quotes table: a symbol
+// without a price keeps its record, with every other field empty.
+func writeQuotesCSV(w http.ResponseWriter, rows []views.QuoteRow, opts csvOptions, showProvenance bool) error {
+	cw := newCSVWriter(w, opts)
+	header := []string{"symbol", "name", "price", "openingPrice", "previousClosingPrice",
+		"priceChangeAbsolute", "priceChangePercentage", "currency", "timestamp"}
+	if showProvenance {
+		header = append(header, "source", "scrapedAt")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, len(header))
+		record[0] = row.Symbol
+		if q := row.Quote; q != nil {
+			copy(record[1:], []string{
+				q.Symbol.Name,
+				opts.formatDecimal(q.Price.Price),
+				opts.formatDecimal(q.Price.OpeningPrice),
+				opts.formatDecimal(q.Price.PreviousClosingPrice),
+				opts.formatDecimal(q.Price.PriceChangeAbsolute().Round(2)),
+				opts.formatDecimal(q.Price.PriceChangePercentage().Round(2)),
+				q.Price.Currency,
+				opts.formatTimestamp(q.Price.Timestamp),
+			})
+			if showProvenance {
+				record[9], record[10] = q.Price.Source, opts.formatScrapedAt(q.Price.ScrapedAt)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -3,13 +3,55 @@ package web
 import (
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/bjarke-xyz/stonks/internal/core"
 	"github.com/bjarke-xyz/stonks/internal/web/views"
+	"github.com/samber/lo"
 )
 
+// maxIndexSearchResults bounds the symbols listed for ?q on the index.
+const maxIndexSearchResults = 50
+
+// HandleGetIndex lists the active symbols with their current price, or with
+// ?q only those matching the search.
 func (h *web) HandleGetIndex(w http.ResponseWriter, r *http.Request) {
-	err := views.Render(w, http.StatusOK, "index.html", views.IndexViewModel{
-		Base: h.getBaseModel(r, "stonks"),
+	ctx := r.Context()
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	var symbols []core.SymbolDetails
+	var err error
+	if query != "" {
+		symbols, err = h.appContext.Deps.SymbolService.SearchSymbols(ctx, query, maxIndexSearchResults)
+	} else {
+		symbols, err = h.appContext.Deps.SymbolService.Symbols(ctx)
+	}
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	symbols = lo.Filter(symbols, func(s core.SymbolDetails, _ int) bool { return s.Active })
+
+	quotes, err := h.appContext.Deps.QuoteService.GetQuotes(ctx, lo.Map(symbols, func(s core.SymbolDetails, _ int) string { return s.Symbol }))
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	now := time.Now()
+	quotesBySymbol := lo.KeyBy(quotes, func(q core.Quote) string { return q.Symbol.Symbol })
+	rows := lo.Map(symbols, func(s core.SymbolDetails, _ int) views.IndexRow {
+		row := views.IndexRow{Symbol: s, Market: h.appContext.Deps.CalendarService.MarketStatus(s.ExchangeID, now)}
+		if quote, ok := quotesBySymbol[s.Symbol]; ok {
+			row.Quote = &quote
+		}
+		return row
+	})
+
+	err = views.Render(w, http.StatusOK, "index.html", views.IndexViewModel{
+		Base:  h.getBaseModel(r, "stonks"),
+		Query: query,
+		Rows:  rows,
 	})
 	if err != nil {
 		slog.Error("rendering index failed", "error", err)
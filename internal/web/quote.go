@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"html/template"
 	"log/slog"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bjarke-xyz/stonks/internal/core"
@@ -15,31 +18,23 @@ import (
 
 func (h *web) HandleGetQuote(w http.ResponseWriter, r *http.Request) {
 	tickerSymbol := r.PathValue("symbol")
-	durationInp := queryOr(r, "duration", "24h")
-	parsedDuration, err := time.ParseDuration(durationInp)
-	if err != nil {
-		parsedDuration = 24 * time.Hour
-	}
-	endDate := pkg.EndOfDay(time.Now().UTC())
-	startDate := endDate.Add(-parsedDuration)
+	format := quoteFormat(w, r)
 
-	ctx := r.Context()
+	var csvOpts csvOptions
+	if format == "csv" {
+		var err error
+		if csvOpts, err = parseCSVOptions(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
-	quote, err := h.appContext.Deps.QuoteService.GetQuote(ctx, tickerSymbol, startDate, endDate)
+	quote, err := h.getQuote(r)
 	if err != nil {
 		h.handleError(w, r, err)
 		return
 	}
-
-	currency := r.URL.Query().Get("currency")
-	if currency != "" {
-		convertedQuote, err := h.appContext.Deps.CurrencyService.ConvertQuoteCurrency(ctx, quote, currency)
-		if err != nil {
-			h.handleError(w, r, fmt.Errorf("error converting currency: %w", err))
-			return
-		}
-		quote = convertedQuote
-	}
+	showProvenance := r.URL.Query().Get("provenance") == "true"
 
 	chartSvg := ""
 	includeChart := queryOr(r, "chart", "true")
@@ -50,14 +45,28 @@ func (h *web) HandleGetQuote(w http.ResponseWriter, r *http.Request) {
 	model := views.QuoteViewModel{
 		Base:     h.getBaseModel(r, quote.Symbol.Symbol+" | Quote"),
 		Quote:    quote,
+		Market:   h.appContext.Deps.CalendarService.MarketStatus(quote.Symbol.ExchangeID, time.Now()),
 		ChartSvg: template.HTML(chartSvg),
+
+		ShowProvenance: showProvenance,
 	}
 
-	switch r.URL.Query().Get("format") {
+	switch format {
 	case "table":
 		err = views.Render(w, http.StatusOK, "quote_table.html", model)
 	case "xml":
 		err = writeXML(w, http.StatusOK, quote.ToSerializableQuote())
+	case "json":
+		if quote.HistoricalPrices == nil {
+			// An empty array rather than null, so clients need not tell the two apart.
+			quote.HistoricalPrices = []core.SimplePrice{}
+		}
+		if quote.Bars == nil && r.URL.Query().Get("interval") != "" {
+			quote.Bars = []core.Bar{}
+		}
+		err = writeJSON(w, http.StatusOK, quote.ToSerializableQuote())
+	case "csv":
+		err = writeQuoteCSV(w, quote, r.URL.Query().Get("interval"), csvOpts, showProvenance)
 	default:
 		err = views.Render(w, http.StatusOK, "quote.html", model)
 	}
@@ -66,6 +75,119 @@ func (h *web) HandleGetQuote(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// HandleGetQuoteHistory serves the historical prices of /quote/{symbol} as
+// CSV, for spreadsheet imports such as Google Sheets' IMPORTDATA. It takes the
+// same duration, interval, currency and provenance parameters, and the
+// csvOptions ones.
+func (h *web) HandleGetQuoteHistory(w http.ResponseWriter, r *http.Request) {
+	csvOpts, err := parseCSVOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	quote, err := h.getQuote(r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	showProvenance := r.URL.Query().Get("provenance") == "true"
+	if err := writeQuoteCSV(w, quote, r.URL.Query().Get("interval"), csvOpts, showProvenance); err != nil {
+		slog.Error("writing quote history failed", "symbol", quote.Symbol.Symbol, "error", err)
+	}
+}
+
+// writeQuoteCSV writes the quote's bars if it was asked for in an interval,
+// and its historical prices otherwise. The interval is passed rather than
+// read off quote.Bars, which is nil for a cached quote without bars.
+func writeQuoteCSV(w http.ResponseWriter, quote core.Quote, interval string, opts csvOptions, showProvenance bool) error {
+	if interval != "" {
+		return writeBarsCSV(w, quote.Bars, opts)
+	}
+	return writeCSV(w, quote.HistoricalPrices, opts, showProvenance)
+}
+
+// getQuote gets the quote of the symbol in the path over the requested
+// duration, converted to the requested currency. With ?interval, one of
+// db.PriceBarIntervals, the historical prices are aggregated into bars.
+func (h *web) getQuote(r *http.Request) (core.Quote, error) {
+	tickerSymbol := r.PathValue("symbol")
+	startDate, endDate := quoteRange(r)
+
+	ctx := r.Context()
+
+	var quote core.Quote
+	var err error
+	if interval := r.URL.Query().Get("interval"); interval != "" {
+		quote, err = h.appContext.Deps.QuoteService.GetQuoteBars(ctx, tickerSymbol, interval, startDate, endDate)
+	} else {
+		quote, err = h.appContext.Deps.QuoteService.GetQuote(ctx, tickerSymbol, startDate, endDate)
+	}
+	if err != nil {
+		return core.Quote{}, err
+	}
+
+	currency := r.URL.Query().Get("currency")
+	if currency != "" {
+		convertedQuote, err := h.appContext.Deps.CurrencyService.ConvertQuoteCurrency(ctx, quote, currency)
+		if err != nil {
+			return core.Quote{}, fmt.Errorf("error converting currency: %w", err)
+		}
+		quote = convertedQuote
+	}
+
+	// Provenance is opt-in, so existing spreadsheets keep seeing the same
+	// columns and elements.
+	if r.URL.Query().Get("provenance") != "true" {
+		quote = quote.WithoutProvenance()
+	}
+	return quote, nil
+}
+
+// quoteRange is the ?duration, 24h by default, up to the end of today.
+func quoteRange(r *http.Request) (time.Time, time.Time) {
+	duration, err := time.ParseDuration(queryOr(r, "duration", "24h"))
+	if err != nil {
+		duration = 24 * time.Hour
+	}
+	endDate := pkg.EndOfDay(time.Now().UTC())
+	return endDate.Add(-duration), endDate
+}
+
+// quoteFormat is the ?format parameter or, without one, "json" when the
+// Accept header prefers JSON to HTML. Only the two listed by name compete, so
+// "application/json, */*" still picks JSON. The higher q-value wins, a tie goes
+// to the one listed first, and q=0 rules a type out. A negotiated format makes
+// the response vary by Accept, which caches are told.
+func quoteFormat(w http.ResponseWriter, r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+	w.Header().Add("Vary", "Accept")
+	format, bestQ := "", 0.0
+	for mediaRange := range strings.SplitSeq(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		switch mediaType {
+		case "application/json":
+			format, bestQ = "json", q
+		case "text/html":
+			format, bestQ = "", q
+		}
+	}
+	return format
+}
+
 func makeChart(quote core.Quote) string {
 	if len(quote.HistoricalPrices) == 0 {
 		return ""
@@ -3,12 +3,14 @@ package web
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"encoding/xml"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/bjarke-xyz/stonks/internal/calendar"
 	"github.com/bjarke-xyz/stonks/internal/config"
 	"github.com/bjarke-xyz/stonks/internal/core"
 	"github.com/shopspring/decimal"
@@ -22,6 +24,49 @@ func (s stubQuoteService) GetQuote(ctx context.Context, tickerSymbol string, sta
 	return q, nil
 }
 
+// GetQuotes knows every symbol except NOPE.
+func (s stubQuoteService) GetQuotes(ctx context.Context, tickerSymbols []string) ([]core.Quote, error) {
+	var quotes []core.Quote
+	for _, ticker := range tickerSymbols {
+		if ticker == "NOPE" {
+			continue
+		}
+		q := s.quote
+		q.Symbol.Symbol = ticker
+		q.HistoricalPrices = nil
+		quotes = append(quotes, q)
+	}
+	return quotes, nil
+}
+
+// GetQuotesHistory keeps the historical prices of the stub quote.
+func (s stubQuoteService) GetQuotesHistory(ctx context.Context, tickerSymbols []string, startDate, endDate time.Time) ([]core.Quote, error) {
+	quotes, _ := s.GetQuotes(ctx, tickerSymbols)
+	for i := range quotes {
+		quotes[i].HistoricalPrices = s.quote.HistoricalPrices
+	}
+	return quotes, nil
+}
+
+// GetQuoteBars makes one daily bar of the historical prices.
+func (s stubQuoteService) GetQuoteBars(ctx context.Context, tickerSymbol string, interval string, startDate, endDate time.Time) (core.Quote, error) {
+	q, _ := s.GetQuote(ctx, tickerSymbol, startDate, endDate)
+	first, last := q.HistoricalPrices[0], q.HistoricalPrices[len(q.HistoricalPrices)-1]
+	q.Bars = []core.Bar{{
+		Start:    first.Timestamp.Truncate(24 * time.Hour),
+		Open:     first.Price,
+		High:     last.Price,
+		Low:      first.Price,
+		Close:    last.Price,
+		Currency: first.Currency,
+		Count:    len(q.HistoricalPrices),
+	}}
+	q.HistoricalPrices = []core.SimplePrice{{Price: last.Price, Currency: last.Currency, Timestamp: q.Bars[0].Start}}
+	return q, nil
+}
+
+func (s stubQuoteService) RebuildBars(ctx context.Context) (int, error) { return 0, nil }
+
 func (s stubQuoteService) ClearCache(ctx context.Context, tickerSymbol string) error { return nil }
 
 func testQuote() core.Quote {
@@ -46,7 +91,10 @@ func newTestServer(t *testing.T) *http.ServeMux {
 	t.Helper()
 	h := NewWeb(&core.AppContext{
 		Config: &config.Config{},
-		Deps:   &core.AppDeps{QuoteService: stubQuoteService{quote: testQuote()}},
+		Deps: &core.AppDeps{
+			QuoteService:    stubQuoteService{quote: testQuote()},
+			CalendarService: calendar.NewCalendarService(),
+		},
 	})
 	mux := http.NewServeMux()
 	h.Route(mux)
@@ -78,6 +126,190 @@ func TestQuoteXMLMatchesGinEncoding(t *testing.T) {
 	}
 }
 
+// The JSON field names are part of the API: changing one breaks clients.
+func TestQuoteJSONFieldNames(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestServer(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quote/AAPL?format=json", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got, want := rec.Header().Get("Content-Type"), "application/json; charset=utf-8"; got != want {
+		t.Errorf("Content-Type = %q, want %q", got, want)
+	}
+
+	want := `{
+		"symbol": {"symbol": "AAPL", "name": "Apple Inc."},
+		"price": {
+			"price": "212.5",
+			"currency": "USD",
+			"timestamp": "2026-07-08T12:00:00Z",
+			"openingPrice": "210",
+			"previousClosingPrice": "209.25",
+			"priceChangeAbsolute": "3.25",
+			"priceChangePercentage": "1.5531660692951016"
+		},
+		"historicalPrices": [
+			{"price": "210", "currency": "USD", "timestamp": "2026-07-08T12:00:00Z"},
+			{"price": "212.5", "currency": "USD", "timestamp": "2026-07-08T13:00:00Z"}
+		]
+	}`
+	var got, wantValue any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("unmarshal want: %v", err)
+	}
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(wantValue)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("body mismatch\n got: %s\nwant: %s", gotJSON, wantJSON)
+	}
+}
+
+func TestQuoteCSV(t *testing.T) {
+	mux := newTestServer(t)
+	tests := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{"defaults", "/quote/AAPL?format=csv",
+			"timestamp,price,currency\n2026-07-08T12:00:00Z,210,USD\n2026-07-08T13:00:00Z,212.5,USD\n"},
+		{"history endpoint", "/quote/AAPL/history?delimiter=semicolon&decimal=,&timestampFormat=datetime",
+			"timestamp;price;currency\n2026-07-08 12:00:00;210;USD\n2026-07-08 13:00:00;212,5;USD\n"},
+		{"decimal comma is quoted", "/quote/AAPL/history?decimal=,&timestampFormat=unix",
+			"timestamp,price,currency\n1783512000,210,USD\n1783515600,\"212,5\",USD\n"},
+		{"daily bars", "/quote/AAPL/history?interval=1d&timestampFormat=date",
+			"timestamp,open,high,low,close,currency,count\n2026-07-08,210,212.5,210,212.5,USD,2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			if got, want := rec.Header().Get("Content-Type"), "text/csv; charset=utf-8"; got != want {
+				t.Errorf("Content-Type = %q, want %q", got, want)
+			}
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("body mismatch\n got: %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quote/AAPL/history?timestampFormat=nope", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid timestampFormat status = %d, want 400", rec.Code)
+	}
+
+	// A cached quote without bars decodes with nil Bars, and is still written
+	// as bars when asked for in an interval.
+	opts, err := parseCSVOptions(httptest.NewRequest(http.MethodGet, "/quote/AAPL/history", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	if err := writeQuoteCSV(rec, testQuote(), "1d", opts, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.Body.String(), "timestamp,open,high,low,close,currency,count\n"; got != want {
+		t.Errorf("interval without bars\n got: %q\nwant: %q", got, want)
+	}
+}
+
+// A symbol without a price keeps its row, so the rows of a spreadsheet import
+// stay aligned with the symbols it lists.
+func TestQuotesKeepRowPerSymbol(t *testing.T) {
+	mux := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quotes?symbols=aapl,NOPE,EUNL&format=csv", nil))
+	want := "symbol,name,price,openingPrice,previousClosingPrice,priceChangeAbsolute,priceChangePercentage,currency,timestamp\n" +
+		"AAPL,Apple Inc.,212.5,210,209.25,3.25,1.55,USD,2026-07-08T12:00:00Z\n" +
+		"NOPE,,,,,,,,\n" +
+		"EUNL,Apple Inc.,212.5,210,209.25,3.25,1.55,USD,2026-07-08T12:00:00Z\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("csv mismatch\n got: %q\nwant: %q", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quotes?symbols=AAPL,NOPE,EUNL", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("table status = %d, want 200", rec.Code)
+	}
+	if got := bytes.Count(rec.Body.Bytes(), []byte("<td>NOPE</td>")); got != 1 {
+		t.Errorf("table has %d rows for NOPE, want 1", got)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quotes?symbols=AAPL,NOPE&format=json", nil))
+	var quotes []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &quotes); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(quotes) != 1 {
+		t.Errorf("json lists %d quotes, want 1", len(quotes))
+	}
+	if history, _ := quotes[0]["historicalPrices"].([]any); len(history) != 0 {
+		t.Errorf("json has %d historical prices without a duration, want 0", len(history))
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quotes?symbols=AAPL,NOPE&format=json&duration=48h", nil))
+	quotes = nil
+	if err := json.Unmarshal(rec.Body.Bytes(), &quotes); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if history, _ := quotes[0]["historicalPrices"].([]any); len(quotes) != 1 || len(history) != 2 {
+		t.Errorf("json with a duration lists %d quotes with %d historical prices, want 1 with 2", len(quotes), len(history))
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quotes", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("no symbols status = %d, want 400", rec.Code)
+	}
+}
+
+func TestQuoteAcceptNegotiation(t *testing.T) {
+	mux := newTestServer(t)
+	tests := []struct {
+		accept    string
+		wantCtype string
+	}{
+		{"application/json", "application/json; charset=utf-8"},
+		{"application/json;q=0.9, */*", "application/json; charset=utf-8"},
+		{"text/html,application/xhtml+xml,application/json;q=0.9", "text/html; charset=utf-8"},
+		{"*/*", "text/html; charset=utf-8"},
+		{"", "text/html; charset=utf-8"},
+		{"application/json;q=0", "text/html; charset=utf-8"},
+		{"application/json; q=0, text/html", "text/html; charset=utf-8"},
+		{"text/html;q=0.5, application/json", "application/json; charset=utf-8"},
+		{"text/html;level=1;q=0.2, application/json;q=0.8", "application/json; charset=utf-8"},
+		{"application/json, text/html", "application/json; charset=utf-8"},
+		{"text/html, application/json", "text/html; charset=utf-8"},
+		{"application/json;q=bogus, text/html;q=0.1", "text/html; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/quote/AAPL?chart=false", nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if got := rec.Header().Get("Content-Type"); got != tt.wantCtype {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantCtype)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %q, want Accept", got)
+			}
+		})
+	}
+}
+
 func TestQuoteRoutingAndFormats(t *testing.T) {
 	mux := newTestServer(t)
 	tests := []struct {
@@ -90,6 +322,7 @@ func TestQuoteRoutingAndFormats(t *testing.T) {
 		{"html default", "/quote/AAPL?chart=false", 200, "text/html; charset=utf-8", true},
 		{"table format", "/quote/AAPL?format=table&chart=false", 200, "text/html; charset=utf-8", true},
 		{"xml format", "/quote/AAPL?format=xml", 200, "application/xml; charset=utf-8", true},
+		{"json format", "/quote/AAPL?format=json", 200, "application/json; charset=utf-8", true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,115 @@
+package web
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/bjarke-xyz/stonks/internal/core"
+	"github.com/bjarke-xyz/stonks/internal/web/views"
+	"github.com/samber/lo"
+)
+
+// maxQuotesSymbols bounds ?symbols of /quotes.
+const maxQuotesSymbols = 100
+
+// serializableQuotes is the XML document of /quotes?format=xml.
+type serializableQuotes struct {
+	XMLName xml.Name                 `xml:"Quotes"`
+	Quotes  []core.SerializableQuote `xml:"Quote"`
+}
+
+// HandleGetQuotes serves the current price of every symbol in
+// ?symbols=EUNL,AAPL as one table, so a portfolio sheet needs a single import.
+// It takes the format, currency and provenance parameters of /quote/{symbol},
+// and with ?duration the JSON and XML include each symbol's historical prices.
+// The table and CSV keep a row per requested symbol, in order; JSON and XML
+// list only the symbols that have a price.
+func (h *web) HandleGetQuotes(w http.ResponseWriter, r *http.Request) {
+	tickers := lo.Compact(lo.Map(strings.Split(r.URL.Query().Get("symbols"), ","), func(t string, _ int) string {
+		return strings.ToUpper(strings.TrimSpace(t))
+	}))
+	if len(tickers) == 0 {
+		http.Error(w, "symbols is required", http.StatusBadRequest)
+		return
+	}
+	if len(tickers) > maxQuotesSymbols {
+		http.Error(w, fmt.Sprintf("at most %v symbols are allowed", maxQuotesSymbols), http.StatusBadRequest)
+		return
+	}
+
+	format := quoteFormat(w, r)
+	var csvOpts csvOptions
+	if format == "csv" {
+		var err error
+		if csvOpts, err = parseCSVOptions(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	var quotes []core.Quote
+	var err error
+	if r.URL.Query().Has("duration") {
+		startDate, endDate := quoteRange(r)
+		quotes, err = h.appContext.Deps.QuoteService.GetQuotesHistory(ctx, tickers, startDate, endDate)
+	} else {
+		quotes, err = h.appContext.Deps.QuoteService.GetQuotes(ctx, tickers)
+	}
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	currency := r.URL.Query().Get("currency")
+	showProvenance := r.URL.Query().Get("provenance") == "true"
+	for i, quote := range quotes {
+		if currency != "" {
+			quote, err = h.appContext.Deps.CurrencyService.ConvertQuoteCurrency(ctx, quote, currency)
+			if err != nil {
+				h.handleError(w, r, fmt.Errorf("error converting currency of %v: %w", quote.Symbol.Symbol, err))
+				return
+			}
+		}
+		if !showProvenance {
+			quote = quote.WithoutProvenance()
+		}
+		quotes[i] = quote
+	}
+
+	quotesBySymbol := lo.KeyBy(quotes, func(q core.Quote) string { return q.Symbol.Symbol })
+	rows := lo.Map(tickers, func(ticker string, _ int) views.QuoteRow {
+		row := views.QuoteRow{Symbol: ticker, ShowProvenance: showProvenance}
+		if quote, ok := quotesBySymbol[ticker]; ok {
+			row.Quote = &quote
+		}
+		return row
+	})
+	serializable := lo.Map(quotes, func(q core.Quote, _ int) core.SerializableQuote {
+		if q.HistoricalPrices == nil {
+			q.HistoricalPrices = []core.SimplePrice{}
+		}
+		return q.ToSerializableQuote()
+	})
+
+	switch format {
+	case "xml":
+		err = writeXML(w, http.StatusOK, serializableQuotes{Quotes: serializable})
+	case "json":
+		err = writeJSON(w, http.StatusOK, serializable)
+	case "csv":
+		err = writeQuotesCSV(w, rows, csvOpts, showProvenance)
+	default:
+		err = views.Render(w, http.StatusOK, "quotes_table.html", views.QuotesViewModel{
+			Base:           h.getBaseModel(r, "Quotes"),
+			Rows:           rows,
+			ShowProvenance: showProvenance,
+		})
+	}
+	if err != nil {
+		slog.Error("rendering quotes failed", "symbols", tickers, "error", err)
+	}
+}
@@ -20,13 +20,16 @@ var funcs = template.FuncMap{
 }
 
 // Each page is parsed into its own template set, because every page defines a
-// template named "content" that layout.html renders.
+// template named "content" that layout.html renders. Partials are shared
+// templates that any page may use.
 var pages = map[string]*template.Template{}
 
+var partials = []string{"quote_row.html"}
+
 func init() {
-	for _, page := range []string{"index.html", "quote.html", "quote_table.html", "error.html"} {
+	for _, page := range []string{"index.html", "quote.html", "quote_table.html", "quotes_table.html", "error.html"} {
 		pages[page] = template.Must(
-			template.New(page).Funcs(funcs).ParseFS(files, "layout.html", page))
+			template.New(page).Funcs(funcs).ParseFS(files, append([]string{"layout.html", page}, partials...)...))
 	}
 }
 
@@ -36,8 +39,19 @@ type BaseViewModel struct {
 	Title         string
 }
 
+// IndexViewModel lists the tracked symbols, or those matching Query.
 type IndexViewModel struct {
-	Base BaseViewModel
+	Base  BaseViewModel
+	Query string
+	Rows  []IndexRow
+}
+
+// IndexRow is a symbol and its current price. Quote is nil for a symbol that
+// has not been scraped yet.
+type IndexRow struct {
+	Symbol core.SymbolDetails
+	Quote  *core.Quote
+	Market core.MarketStatus
 }
 
 type ErrViewModel struct {
@@ -48,7 +62,32 @@ type ErrViewModel struct {
 type QuoteViewModel struct {
 	Base     BaseViewModel
 	Quote    core.Quote
+	Market   core.MarketStatus
 	ChartSvg template.HTML
+
+	ShowProvenance bool
+}
+
+// Row is the quote as a row of the current price table.
+func (m QuoteViewModel) Row() QuoteRow {
+	return QuoteRow{Symbol: m.Quote.Symbol.Symbol, Quote: &m.Quote, ShowProvenance: m.ShowProvenance}
+}
+
+// QuotesViewModel is the current price table of several symbols.
+type QuotesViewModel struct {
+	Base BaseViewModel
+	Rows []QuoteRow
+
+	ShowProvenance bool
+}
+
+// QuoteRow is one row of the current price table. Quote is nil for a symbol
+// without a price; its row is kept, empty, so a spreadsheet importing the
+// table finds every symbol on the row it asked for it.
+type QuoteRow struct {
+	Symbol         string
+	Quote          *core.Quote
+	ShowProvenance bool
 }
 
 // Render writes the named page wrapped in layout.html. Output is buffered so a
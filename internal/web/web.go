@@ -2,7 +2,9 @@ package web
 
 import (
 	"embed"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"io/fs"
 	"log/slog"
 	"net/http"
@@ -30,6 +32,8 @@ func (h *web) Route(mux *http.ServeMux) {
 	// swallow every otherwise-unmatched path. GET patterns also serve HEAD.
 	mux.HandleFunc("GET /{$}", h.HandleGetIndex)
 	mux.HandleFunc("GET /quote/{symbol}", h.HandleGetQuote)
+	mux.HandleFunc("GET /quote/{symbol}/history", h.HandleGetQuoteHistory)
+	mux.HandleFunc("GET /quotes", h.HandleGetQuotes)
 	// gin redirected /quote/AAPL/ to /quote/AAPL. ServeMux would 404 it, so keep
 	// the redirect for bookmarked or hand-typed URLs.
 	mux.HandleFunc("GET /quote/{symbol}/{$}", redirectTrailingSlash)
@@ -58,8 +62,12 @@ func (h *web) getBaseModel(r *http.Request, title string) views.BaseViewModel {
 }
 
 func (h *web) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, core.ErrInvalidInput) {
+		status = http.StatusBadRequest
+	}
 	slog.Error("handler error", "method", r.Method, "path", r.URL.Path, "error", err)
-	if renderErr := views.Render(w, http.StatusInternalServerError, "error.html", views.ErrViewModel{
+	if renderErr := views.Render(w, status, "error.html", views.ErrViewModel{
 		Base:  h.getBaseModel(r, "error"),
 		Error: err,
 	}); renderErr != nil {
@@ -83,6 +91,13 @@ func writeXML(w http.ResponseWriter, status int, data any) error {
 	return xml.NewEncoder(w).Encode(data)
 }
 
+// writeJSON writes data as a single JSON document, timestamps in RFC 3339.
+func writeJSON(w http.ResponseWriter, status int, data any) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(data)
+}
+
 func staticFiles(mux *http.ServeMux, staticFs fs.FS) {
 	staticWeb, err := fs.Sub(staticFs, "static")
 	if err != nil {
@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateISIN checks that isin is an ISIN: a two letter country code, nine
+// letters or digits, and a check digit that matches the rest. Letters must be
+// upper case.
+func ValidateISIN(isin string) error {
+	if len(isin) != 12 {
+		return fmt.Errorf("isin %q is not 12 characters long", isin)
+	}
+	for i, c := range isin {
+		isLetter := c >= 'A' && c <= 'Z'
+		isDigit := c >= '0' && c <= '9'
+		switch {
+		case i < 2 && !isLetter:
+			return fmt.Errorf("isin %q does not start with a country code", isin)
+		case i < 11 && !isLetter && !isDigit:
+			return fmt.Errorf("isin %q contains %q", isin, c)
+		case i == 11 && !isDigit:
+			return fmt.Errorf("isin %q does not end with a check digit", isin)
+		}
+	}
+	if !luhnValid(isinDigits(isin)) {
+		return fmt.Errorf("isin %q has the wrong check digit", isin)
+	}
+	return nil
+}
+
+// IsISIN reports whether s is a valid ISIN.
+func IsISIN(s string) bool {
+	return ValidateISIN(s) == nil
+}
+
+// isinDigits spells out an ISIN's letters as numbers, A as 10 up to Z as 35,
+// which is what the check digit is computed over.
+func isinDigits(isin string) string {
+	var b strings.Builder
+	for _, c := range isin {
+		if c >= 'A' && c <= 'Z' {
+			fmt.Fprintf(&b, "%d", c-'A'+10)
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// luhnValid reports whether the last digit of digits is its Luhn check digit.
+func luhnValid(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,25 @@
+package pkg
+
+import "testing"
+
+func TestValidateISIN(t *testing.T) {
+	tests := []struct {
+		isin  string
+		valid bool
+	}{
+		{"IE00B4L5Y983", true},
+		{"US0378331005", true},
+		{"DE0005140008", true},
+		{"IE00B4L5Y984", false}, // wrong check digit
+		{"US0378331050", false}, // transposed digits
+		{"ie00b4l5y983", false},
+		{"0E00B4L5Y983", false},
+		{"IE00B4L5Y98", false},
+		{"IE00B4L5Y98X", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateISIN(tt.isin); (err == nil) != tt.valid {
+			t.Errorf("ValidateISIN(%q) = %v, want valid %v", tt.isin, err, tt.valid)
+		}
+	}
+}